@@ -0,0 +1,5 @@
+module github.com/nmmh/magneturi
+
+go 1.22
+
+require google.golang.org/protobuf v1.36.7
@@ -0,0 +1,335 @@
+// Package announce asks trackers for peers of the torrents behind a
+// magnet link, over HTTP or the BEP 15 UDP tracker protocol.
+package announce
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nmmh/magneturi/magneturi"
+	"github.com/nmmh/magneturi/magneturi/bencode"
+	"github.com/nmmh/magneturi/magneturi/internal/udptracker"
+)
+
+// Event is the announce event.
+type Event int
+
+// The announce events, None for regular announces.
+const (
+	None Event = iota
+	Completed
+	Started
+	Stopped
+)
+
+var eventNames = []string{"", "completed", "started", "stopped"}
+
+// Request is one announce.
+type Request struct {
+	InfoHash magneturi.InfoHash
+	// PeerID identifies us, Client.PeerID is used if zero.
+	PeerID [20]byte
+	// Port is where we accept peer connections.
+	Port       uint16
+	Uploaded   int64
+	Downloaded int64
+	// Left is how many bytes we still miss, -1 when it is not known
+	// as for a magnet link without metadata.
+	Left  int64
+	Event Event
+	// NumWant is how many peers to ask for, the tracker decides if 0.
+	NumWant int
+}
+
+// Response is what the tracker answered.
+type Response struct {
+	Interval time.Duration
+	Seeders  int
+	Leechers int
+	Peers    []Peer
+}
+
+// Peer is a peer endpoint, ID is only set by HTTP trackers answering
+// in the non-compact format.
+type Peer struct {
+	ID   string
+	Addr netip.AddrPort
+}
+
+// Client announces to trackers, the zero value is ready to use.
+type Client struct {
+	// HTTPClient is used for HTTP trackers, http.DefaultClient if nil.
+	HTTPClient *http.Client
+	// Retries is how often a failed request is repeated.
+	Retries int
+	// Timeout is how long the first attempt at a UDP request waits
+	// for an answer, it doubles with every retry. Defaults to
+	// udptracker.DefaultTimeout.
+	Timeout time.Duration
+	// PeerID is used for requests without one, a random one is
+	// made on first use if it is zero.
+	PeerID [20]byte
+	// Port is used for requests without one.
+	Port uint16
+	// NonCompact asks HTTP trackers for the dictionary peer list.
+	NonCompact bool
+
+	peerIDOnce sync.Once
+}
+
+// Announce sends req to the tracker with the given announce URL.
+func (c *Client) Announce(ctx context.Context, announceURL string, req Request) (Response, error) {
+	t, err := magneturi.ParseTracker(announceURL)
+	if err != nil {
+		return Response{}, err
+	}
+	return c.announce(ctx, t, req)
+}
+
+func (c *Client) announce(ctx context.Context, t magneturi.Tracker, req Request) (Response, error) {
+	if req.PeerID == ([20]byte{}) {
+		c.peerIDOnce.Do(func() {
+			if c.PeerID == ([20]byte{}) {
+				//Azureus style peer id, the client name followed by random bytes
+				copy(c.PeerID[:], "-MU0001-")
+				rand.Read(c.PeerID[8:])
+			}
+		})
+		req.PeerID = c.PeerID
+	}
+	if req.Port == 0 {
+		req.Port = c.Port
+	}
+	switch t.Scheme {
+	case "udp":
+		return c.announceUDP(ctx, t, req)
+	case "http", "https":
+		return c.announceHTTP(ctx, t, req)
+	}
+	return Response{}, fmt.Errorf("announce: unsupported tracker scheme %q", t.Scheme)
+}
+
+// Peers announces every info-hash of m to its trackers and returns the
+// distinct peers. Trackers are tried tier by tier following BEP 12:
+// within a tier the first tracker that answers is promoted to the
+// front and the lower tiers are only asked when a whole tier failed.
+// The error is only set when no tracker answered at all.
+func (c *Client) Peers(ctx context.Context, m *magneturi.MagnetURI, req Request) ([]Peer, error) {
+	hashes := m.InfoHashes()
+	if len(hashes) == 0 {
+		return nil, fmt.Errorf("announce: magnet uri has no btih exact topic")
+	}
+	list := m.AnnounceList()
+	if len(list) == 0 {
+		return nil, fmt.Errorf("announce: magnet uri has no usable tracker")
+	}
+	var peers []Peer
+	seen := map[netip.AddrPort]bool{}
+	var errs []error
+	answered := false
+	for _, h := range hashes {
+		req.InfoHash = h
+		for _, tier := range list {
+			resp, err := c.announceTier(ctx, tier, req, list)
+			if err != nil {
+				errs = append(errs, err)
+				if ctx.Err() != nil {
+					return peers, ctx.Err()
+				}
+				continue
+			}
+			answered = true
+			for _, p := range resp.Peers {
+				if !seen[p.Addr] {
+					seen[p.Addr] = true
+					peers = append(peers, p)
+				}
+			}
+			break
+		}
+	}
+	if !answered {
+		return nil, errors.Join(errs...)
+	}
+	return peers, nil
+}
+
+func (c *Client) announceTier(ctx context.Context, tier []magneturi.Tracker, req Request, list magneturi.AnnounceList) (Response, error) {
+	var errs []error
+	for _, t := range tier {
+		resp, err := c.announce(ctx, t, req)
+		if err == nil {
+			list.Promote(t)
+			return resp, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", t.Host, err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return Response{}, errors.Join(errs...)
+}
+
+func (c *Client) announceHTTP(ctx context.Context, t magneturi.Tracker, req Request) (Response, error) {
+	u := *t.URL
+	q := u.Query()
+	q.Set("info_hash", string(req.InfoHash[:]))
+	q.Set("peer_id", string(req.PeerID[:]))
+	q.Set("port", strconv.Itoa(int(req.Port)))
+	q.Set("uploaded", strconv.FormatInt(req.Uploaded, 10))
+	q.Set("downloaded", strconv.FormatInt(req.Downloaded, 10))
+	q.Set("left", strconv.FormatInt(left(req.Left), 10))
+	if c.NonCompact {
+		q.Set("compact", "0")
+	} else {
+		q.Set("compact", "1")
+	}
+	if req.Event != None {
+		q.Set("event", eventNames[req.Event])
+	}
+	if req.NumWant > 0 {
+		q.Set("numwant", strconv.Itoa(req.NumWant))
+	}
+	u.RawQuery = q.Encode()
+
+	var body []byte
+	err := udptracker.Retry(ctx, c.Retries, func(attempt int) error {
+		var err error
+		body, err = c.get(ctx, u.String())
+		return err
+	})
+	if err != nil {
+		return Response{}, err
+	}
+	v, err := bencode.Decode(body)
+	if err != nil {
+		return Response{}, fmt.Errorf("announce: invalid response from %s: %v", t.Host, err)
+	}
+	dict, ok := v.(map[string]interface{})
+	if !ok {
+		return Response{}, fmt.Errorf("announce: invalid response from %s: not a dictionary", t.Host)
+	}
+	if reason, ok := dict["failure reason"].(string); ok {
+		return Response{}, fmt.Errorf("announce: %s: %s", t.Host, reason)
+	}
+	interval, _ := dict["interval"].(int64)
+	seeders, _ := dict["complete"].(int64)
+	leechers, _ := dict["incomplete"].(int64)
+	resp := Response{
+		Interval: time.Duration(interval) * time.Second,
+		Seeders:  int(seeders),
+		Leechers: int(leechers),
+	}
+	switch peers := dict["peers"].(type) {
+	case string:
+		resp.Peers = compactPeers([]byte(peers), 4)
+	case []interface{}:
+		for _, item := range peers {
+			p, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			ip, _ := p["ip"].(string)
+			port, _ := p["port"].(int64)
+			id, _ := p["peer id"].(string)
+			addr, err := netip.ParseAddr(ip)
+			if err != nil || port <= 0 || port > 65535 {
+				//host names are allowed but not resolved here
+				continue
+			}
+			resp.Peers = append(resp.Peers, Peer{id, netip.AddrPortFrom(addr.Unmap(), uint16(port))})
+		}
+	}
+	if peers6, ok := dict["peers6"].(string); ok {
+		resp.Peers = append(resp.Peers, compactPeers([]byte(peers6), 16)...)
+	}
+	return resp, nil
+}
+
+func (c *Client) get(ctx context.Context, u string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("announce: %s: %s", req.URL.Host, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+func (c *Client) announceUDP(ctx context.Context, t magneturi.Tracker, req Request) (Response, error) {
+	conn, err := udptracker.Dial(ctx, t.Host, t.Port, c.Retries, c.Timeout)
+	if err != nil {
+		return Response{}, fmt.Errorf("announce: %s: %w", t.Host, err)
+	}
+	defer conn.Close()
+	body := append(req.InfoHash[:], req.PeerID[:]...)
+	body = binary.BigEndian.AppendUint64(body, uint64(req.Downloaded))
+	body = binary.BigEndian.AppendUint64(body, uint64(left(req.Left)))
+	body = binary.BigEndian.AppendUint64(body, uint64(req.Uploaded))
+	body = binary.BigEndian.AppendUint32(body, uint32(req.Event))
+	//IP address 0, the tracker uses the sender's
+	body = binary.BigEndian.AppendUint32(body, 0)
+	var key [4]byte
+	rand.Read(key[:])
+	body = append(body, key[:]...)
+	numWant := int32(-1)
+	if req.NumWant > 0 {
+		numWant = int32(req.NumWant)
+	}
+	body = binary.BigEndian.AppendUint32(body, uint32(numWant))
+	body = binary.BigEndian.AppendUint16(body, req.Port)
+	resp, err := conn.Do(ctx, udptracker.ActionAnnounce, body, 20)
+	if err != nil {
+		return Response{}, fmt.Errorf("announce: %s: %w", t.Host, err)
+	}
+	ipLen := 4
+	if conn.IPv6() {
+		ipLen = 16
+	}
+	return Response{
+		Interval: time.Duration(binary.BigEndian.Uint32(resp[8:12])) * time.Second,
+		Leechers: int(binary.BigEndian.Uint32(resp[12:16])),
+		Seeders:  int(binary.BigEndian.Uint32(resp[16:20])),
+		Peers:    compactPeers(resp[20:], ipLen),
+	}, nil
+}
+
+// left reports an unknown amount as a large number, what clients
+// send for magnet links so trackers do not take them for seeders.
+func left(n int64) int64 {
+	if n < 0 {
+		return 1<<53 - 1
+	}
+	return n
+}
+
+// compactPeers decodes the compact peer format, ipLen bytes of
+// address followed by the port in network byte order.
+func compactPeers(b []byte, ipLen int) []Peer {
+	var peers []Peer
+	for ; len(b) >= ipLen+2; b = b[ipLen+2:] {
+		addr, _ := netip.AddrFromSlice(b[:ipLen])
+		port := binary.BigEndian.Uint16(b[ipLen:])
+		peers = append(peers, Peer{Addr: netip.AddrPortFrom(addr, port)})
+	}
+	return peers
+}
@@ -0,0 +1,142 @@
+package announce
+
+import (
+	"context"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nmmh/magneturi/magneturi"
+	"github.com/nmmh/magneturi/magneturi/internal/trackertest"
+)
+
+var hash1, _ = magneturi.ParseInfoHash("c12fe1c06bba254a9dc9f519b335aa7c1367a88a")
+
+var swarm = trackertest.Swarm{
+	Seeders:  2,
+	Leechers: 1,
+	Peers: []trackertest.Peer{
+		{ID: "-AA0001-000000000001", Addr: netip.MustParseAddrPort("10.0.0.1:6881")},
+		{ID: "-AA0001-000000000002", Addr: netip.MustParseAddrPort("10.0.0.2:51413")},
+		{ID: "-AA0001-000000000003", Addr: netip.MustParseAddrPort("[2001:db8::1]:6881")},
+	},
+}
+
+func newTracker() *trackertest.Tracker {
+	tr := trackertest.New()
+	tr.SetSwarm(hash1, swarm)
+	return tr
+}
+
+func addrs(peers []Peer) []string {
+	var a []string
+	for _, p := range peers {
+		a = append(a, p.Addr.String())
+	}
+	return a
+}
+
+func TestClient_Announce(t *testing.T) {
+	tr := newTracker()
+	defer tr.Close()
+	tests := []struct {
+		name      string
+		announce  string
+		client    *Client
+		wantPeers []string
+		wantIDs   bool
+	}{
+		{
+			name:      "http compact",
+			announce:  tr.HTTPAnnounceURL(),
+			client:    &Client{Port: 6881},
+			wantPeers: []string{"10.0.0.1:6881", "10.0.0.2:51413", "[2001:db8::1]:6881"},
+		},
+		{
+			name:      "http non-compact",
+			announce:  tr.HTTPAnnounceURL(),
+			client:    &Client{Port: 6881, NonCompact: true},
+			wantPeers: []string{"10.0.0.1:6881", "10.0.0.2:51413", "[2001:db8::1]:6881"},
+			wantIDs:   true,
+		},
+		{
+			name:      "udp",
+			announce:  tr.UDPAnnounceURL(),
+			client:    &Client{Port: 6881},
+			wantPeers: []string{"10.0.0.1:6881", "10.0.0.2:51413"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := tt.client.Announce(context.Background(), tt.announce, Request{
+				InfoHash: hash1,
+				Left:     -1,
+				Event:    Started,
+				NumWant:  20,
+			})
+			if err != nil {
+				t.Fatalf("Client.Announce() error = %v", err)
+			}
+			if resp.Interval != trackertest.AnnounceInterval*time.Second || resp.Seeders != 2 || resp.Leechers != 1 {
+				t.Errorf("Client.Announce() = %+v", resp)
+			}
+			if got := addrs(resp.Peers); !reflect.DeepEqual(got, tt.wantPeers) {
+				t.Errorf("Client.Announce() peers = %v, want %v", got, tt.wantPeers)
+			}
+			if tt.wantIDs && resp.Peers[0].ID != swarm.Peers[0].ID {
+				t.Errorf("Client.Announce() peer id = %q, want %q", resp.Peers[0].ID, swarm.Peers[0].ID)
+			}
+			announces := tr.Announces()
+			got := announces[len(announces)-1]
+			if got.InfoHash != hash1 || got.Port != 6881 || got.Event != "started" || got.NumWant != 20 ||
+				got.Left != 1<<53-1 || string(got.PeerID[:8]) != "-MU0001-" {
+				t.Errorf("tracker received %+v", got)
+			}
+		})
+	}
+}
+
+func TestClient_Peers(t *testing.T) {
+	failing, working, lower := newTracker(), newTracker(), newTracker()
+	defer failing.Close()
+	defer working.Close()
+	defer lower.Close()
+	failing.Fail("torrent not registered")
+	m := magneturi.MustParse("magnet:?xt=urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a" +
+		"&tr.1=" + url.QueryEscape(failing.UDPAnnounceURL()) +
+		"&tr.1=" + url.QueryEscape(working.HTTPAnnounceURL()) +
+		"&tr.2=" + url.QueryEscape(lower.UDPAnnounceURL()))
+	c := &Client{Port: 6881}
+	peers, err := c.Peers(context.Background(), m, Request{Left: -1})
+	if err != nil {
+		t.Fatalf("Client.Peers() error = %v", err)
+	}
+	want := []string{"10.0.0.1:6881", "10.0.0.2:51413", "[2001:db8::1]:6881"}
+	if got := addrs(peers); !reflect.DeepEqual(got, want) {
+		t.Errorf("Client.Peers() = %v, want %v", got, want)
+	}
+	if len(working.Announces()) != 1 || len(lower.Announces()) != 0 {
+		t.Errorf("Client.Peers() went past the first tier that answered")
+	}
+
+	working.Fail("down")
+	peers, err = c.Peers(context.Background(), m, Request{Left: -1})
+	if err != nil || len(peers) != 2 || len(lower.Announces()) != 1 {
+		t.Errorf("Client.Peers() did not fall back to the second tier: %v, %v", addrs(peers), err)
+	}
+
+	lower.Fail("down")
+	if _, err := c.Peers(context.Background(), m, Request{Left: -1}); err == nil {
+		t.Errorf("Client.Peers() with every tracker failing, error = nil")
+	}
+}
+
+func Test_compactPeers(t *testing.T) {
+	got := compactPeers([]byte{10, 0, 0, 1, 0x1a, 0xe1, 10, 0, 0, 2, 0, 80, 1}, 4)
+	want := []string{"10.0.0.1:6881", "10.0.0.2:80"}
+	if !reflect.DeepEqual(addrs(got), want) {
+		t.Errorf("compactPeers() = %v, want %v", addrs(got), want)
+	}
+}
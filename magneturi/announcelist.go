@@ -0,0 +1,125 @@
+package magneturi
+
+import (
+	"bytes"
+	"math/rand"
+	"sort"
+	"strconv"
+)
+
+// AnnounceList is the BEP 12 tracker model, a list of tiers each
+// holding trackers that are tried in order. Clients try every tracker
+// of a tier before falling through to the next one.
+type AnnounceList [][]Tracker
+
+// AnnounceList maps the trackers of the link into tiers. Indexed
+// trackers form the first tiers ordered by index, trackers sharing an
+// index share the tier. Every unindexed tracker then gets a tier of
+// its own in link order. Duplicates and values that do not parse are
+// left out.
+func (m *MagnetURI) AnnounceList() AnnounceList {
+	indexed := map[int][]Tracker{}
+	var indexes []int
+	var unindexed AnnounceList
+	for _, t := range m.Trackers() {
+		n, err := strconv.Atoi(t.Index)
+		if err != nil {
+			unindexed = append(unindexed, []Tracker{t})
+			continue
+		}
+		if _, ok := indexed[n]; !ok {
+			indexes = append(indexes, n)
+		}
+		indexed[n] = append(indexed[n], t)
+	}
+	sort.Ints(indexes)
+	list := make(AnnounceList, 0, len(indexes)+len(unindexed))
+	for _, n := range indexes {
+		list = append(list, indexed[n])
+	}
+	return append(list, unindexed...)
+}
+
+// Shuffle randomizes the order of the trackers within each tier, as
+// BEP 12 asks clients to do once after loading the list. A nil r uses
+// the default source of math/rand.
+func (a AnnounceList) Shuffle(r *rand.Rand) {
+	shuffle := rand.Shuffle
+	if r != nil {
+		shuffle = r.Shuffle
+	}
+	for _, tier := range a {
+		shuffle(len(tier), func(i, j int) { tier[i], tier[j] = tier[j], tier[i] })
+	}
+}
+
+// Promote moves the tracker with the same Key as t to the front of
+// its tier, what a client does after a successful announce. It
+// returns false if the tracker is not in the list.
+func (a AnnounceList) Promote(t Tracker) bool {
+	for _, tier := range a {
+		for i, candidate := range tier {
+			if candidate.Key() == t.Key() {
+				copy(tier[1:i+1], tier[:i])
+				tier[0] = candidate
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Trackers returns the trackers in the order a client tries them.
+func (a AnnounceList) Trackers() []Tracker {
+	var trackers []Tracker
+	for _, tier := range a {
+		trackers = append(trackers, tier...)
+	}
+	return trackers
+}
+
+// MarshalBencode encodes the list as the announce-list value of a
+// .torrent file, a list of tiers that are lists of URL strings.
+func (a AnnounceList) MarshalBencode() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString("l")
+	for _, tier := range a {
+		b.WriteString("l")
+		for _, t := range tier {
+			s := t.String()
+			b.WriteString(strconv.Itoa(len(s)) + ":" + s)
+		}
+		b.WriteString("e")
+	}
+	b.WriteString("e")
+	return b.Bytes(), nil
+}
+
+// SetAnnounceList replaces the trackers of the link with the list,
+// written as tr.N parameters where N is the tier number starting at 1.
+// The trackers take the place of the first tr parameter, or are
+// appended when the link had none.
+func (m *MagnetURI) SetAnnounceList(a AnnounceList) {
+	var trackers []param
+	for i, tier := range a {
+		for _, t := range tier {
+			trackers = append(trackers, param{"tr", strconv.Itoa(i + 1), t.Raw})
+		}
+	}
+	var params []param
+	inserted := false
+	for _, p := range m.params {
+		if p.prefix != "tr" {
+			params = append(params, p)
+			continue
+		}
+		if !inserted {
+			params = append(params, trackers...)
+			inserted = true
+		}
+	}
+	if !inserted {
+		params = append(params, trackers...)
+	}
+	m.params = params
+}
@@ -0,0 +1,102 @@
+package magneturi
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func announceKeys(a AnnounceList) [][]string {
+	var keys [][]string
+	for _, tier := range a {
+		var k []string
+		for _, t := range tier {
+			k = append(k, t.Host)
+		}
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func TestMagnetURI_AnnounceList(t *testing.T) {
+	m := &MagnetURI{
+		params: []param{
+			param{"xt", "", "urn:btih:QHQXPYWMACKDWKP47RRVIV7VOURXFE5Q"},
+			param{"tr", "", "udp://e.example.org:80"},
+			param{"tr", "2", "udp://c.example.org:80"},
+			param{"tr", "1", "udp://a.example.org:80"},
+			param{"tr", "2", "udp://d.example.org:80"},
+			param{"tr", "1", "udp%3A%2F%2Fb.example.org%3A80"},
+			param{"tr", "", "udp://f.example.org:80"},
+			param{"dn", "", "test"},
+		},
+	}
+	list := m.AnnounceList()
+	want := [][]string{
+		{"a.example.org", "b.example.org"},
+		{"c.example.org", "d.example.org"},
+		{"e.example.org"},
+		{"f.example.org"},
+	}
+	if got := announceKeys(list); !reflect.DeepEqual(got, want) {
+		t.Fatalf("MagnetURI.AnnounceList() = %q, want %q", got, want)
+	}
+
+	if !list.Promote(list[1][1]) {
+		t.Errorf("AnnounceList.Promote() = false, want true")
+	}
+	want[1] = []string{"d.example.org", "c.example.org"}
+	if got := announceKeys(list); !reflect.DeepEqual(got, want) {
+		t.Errorf("AnnounceList.Promote() = %q, want %q", got, want)
+	}
+	unknown, _ := ParseTracker("udp://z.example.org:80")
+	if list.Promote(unknown) {
+		t.Errorf("AnnounceList.Promote() of an unknown tracker = true, want false")
+	}
+
+	m.SetAnnounceList(list)
+	wantURI := "magnet:?xt=urn:btih:QHQXPYWMACKDWKP47RRVIV7VOURXFE5Q" +
+		"&tr.1=udp://a.example.org:80&tr.1=udp%3A%2F%2Fb.example.org%3A80" +
+		"&tr.2=udp://d.example.org:80&tr.2=udp://c.example.org:80" +
+		"&tr.3=udp://e.example.org:80&tr.4=udp://f.example.org:80&dn=test"
+	if got := m.String(); got != wantURI {
+		t.Errorf("MagnetURI.SetAnnounceList() = %v, want %v", got, wantURI)
+	}
+	if got := announceKeys(m.AnnounceList()); !reflect.DeepEqual(got, want) {
+		t.Errorf("AnnounceList() after SetAnnounceList() = %q, want %q", got, want)
+	}
+
+	b, err := list.MarshalBencode()
+	if err != nil {
+		t.Fatalf("AnnounceList.MarshalBencode() error = %v", err)
+	}
+	wantB := "ll22:udp://a.example.org:8022:udp://b.example.org:80el22:udp://d.example.org:8022:udp://c.example.org:80el22:udp://e.example.org:80el22:udp://f.example.org:80ee"
+	if string(b) != wantB {
+		t.Errorf("AnnounceList.MarshalBencode() = %s, want %s", b, wantB)
+	}
+}
+
+func TestAnnounceList_Shuffle(t *testing.T) {
+	var list AnnounceList
+	var tier []Tracker
+	for _, raw := range []string{"udp://a.example.org:1", "udp://b.example.org:1", "udp://c.example.org:1", "udp://d.example.org:1"} {
+		tr, _ := ParseTracker(raw)
+		tier = append(tier, tr)
+	}
+	last, _ := ParseTracker("udp://z.example.org:1")
+	list = AnnounceList{tier, {last}}
+	list.Shuffle(rand.New(rand.NewSource(1)))
+	if len(list[0]) != 4 || list[1][0].Host != "z.example.org" {
+		t.Fatalf("AnnounceList.Shuffle() moved trackers between tiers: %q", announceKeys(list))
+	}
+	seen := map[string]bool{}
+	for _, tr := range list[0] {
+		seen[tr.Host] = true
+	}
+	if len(seen) != 4 {
+		t.Errorf("AnnounceList.Shuffle() lost trackers: %q", announceKeys(list))
+	}
+	if got := len(list.Trackers()); got != 5 {
+		t.Errorf("AnnounceList.Trackers() has %d trackers, want 5", got)
+	}
+}
@@ -0,0 +1,304 @@
+// Package bencode implements the BitTorrent serialization format.
+//
+// Decode and Encode work on generic values: integers map to int64,
+// byte strings to string, lists to []interface{} and dictionaries to
+// map[string]interface{}. Unmarshal and Marshal map bencode to Go
+// types, structs use the field tag "bencode" as in
+//
+//	type File struct {
+//		Length int64    `bencode:"length"`
+//		Path   []string `bencode:"path"`
+//		MD5    string   `bencode:"md5sum,omitempty"`
+//	}
+//
+// where "-" skips a field and fields without a tag use the field name.
+// Embedded structs are not flattened. A RawMessage field keeps the exact
+// bytes of a value, e.g. of the info dictionary an info-hash is
+// computed from.
+//
+// Input is limited to DefaultMaxDepth levels of nesting, a Decoder
+// reading from a stream also limits the size of a value.
+package bencode
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Limits applied to untrusted input.
+const (
+	// DefaultMaxDepth is how deep lists and dictionaries may nest.
+	DefaultMaxDepth = 256
+	// DefaultMaxSize is the largest value a Decoder reads, in bytes.
+	DefaultMaxSize = 64 << 20
+)
+
+// Marshaler is implemented by types that bencode themselves.
+type Marshaler interface {
+	MarshalBencode() ([]byte, error)
+}
+
+// Unmarshaler is implemented by types that decode themselves from a
+// single bencoded value. The data must be copied to be kept.
+type Unmarshaler interface {
+	UnmarshalBencode(data []byte) error
+}
+
+// RawMessage is an already bencoded value, Marshal writes it verbatim
+// and Unmarshal stores the exact bytes of the value in it.
+type RawMessage []byte
+
+// MarshalBencode returns r.
+func (r RawMessage) MarshalBencode() ([]byte, error) {
+	if len(r) == 0 {
+		return nil, fmt.Errorf("bencode: empty RawMessage")
+	}
+	return r, nil
+}
+
+// UnmarshalBencode sets *r to a copy of data.
+func (r *RawMessage) UnmarshalBencode(data []byte) error {
+	*r = append((*r)[:0], data...)
+	return nil
+}
+
+// Decode decodes exactly one bencoded value from data.
+func Decode(data []byte) (interface{}, error) {
+	d := decoder{data: data, maxDepth: DefaultMaxDepth}
+	v, err := d.value()
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.data) {
+		return nil, d.errorf("trailing data")
+	}
+	return v, nil
+}
+
+// DecodePrefix decodes the bencoded value at the start of data and
+// returns it with the number of bytes it took, for messages that carry
+// other data after a bencoded header.
+func DecodePrefix(data []byte) (interface{}, int, error) {
+	d := decoder{data: data, maxDepth: DefaultMaxDepth}
+	v, err := d.value()
+	if err != nil {
+		return nil, 0, err
+	}
+	return v, d.pos, nil
+}
+
+// Encode bencodes v, which may be built from the types Decode returns
+// as well as any other type Marshal accepts.
+func Encode(v interface{}) ([]byte, error) {
+	return Marshal(v)
+}
+
+type decoder struct {
+	data []byte
+	pos  int
+	// strict rejects input that is not in canonical form.
+	strict   bool
+	maxDepth int
+	depth    int
+}
+
+func (d *decoder) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("bencode: "+format+" at offset %d", append(args, d.pos)...)
+}
+
+func (d *decoder) value() (interface{}, error) {
+	if d.pos >= len(d.data) {
+		return nil, d.errorf("unexpected end of data")
+	}
+	switch c := d.data[d.pos]; {
+	case c == 'i':
+		return d.integer()
+	case c >= '0' && c <= '9':
+		return d.string()
+	case c == 'l':
+		if err := d.enter(); err != nil {
+			return nil, err
+		}
+		list := []interface{}{}
+		for {
+			more, err := d.more("list")
+			if err != nil {
+				return nil, err
+			}
+			if !more {
+				return list, nil
+			}
+			v, err := d.value()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+	case c == 'd':
+		if err := d.enter(); err != nil {
+			return nil, err
+		}
+		dict := map[string]interface{}{}
+		for prev := (*string)(nil); ; {
+			more, err := d.more("dictionary")
+			if err != nil {
+				return nil, err
+			}
+			if !more {
+				return dict, nil
+			}
+			key, err := d.key(prev)
+			if err != nil {
+				return nil, err
+			}
+			v, err := d.value()
+			if err != nil {
+				return nil, err
+			}
+			dict[key] = v
+			prev = &key
+		}
+	default:
+		return nil, d.errorf("invalid value type %q", c)
+	}
+}
+
+// enter steps into a list or dictionary.
+func (d *decoder) enter() error {
+	if d.depth++; d.maxDepth > 0 && d.depth > d.maxDepth {
+		return d.errorf("nesting deeper than %d", d.maxDepth)
+	}
+	d.pos++
+	return nil
+}
+
+// more reports whether the current list or dictionary has another
+// item, and steps out of it at its end.
+func (d *decoder) more(container string) (bool, error) {
+	if d.pos >= len(d.data) {
+		return false, d.errorf("unterminated %s", container)
+	}
+	if d.data[d.pos] == 'e' {
+		d.pos++
+		d.depth--
+		return false, nil
+	}
+	return true, nil
+}
+
+// key reads a dictionary key, in strict mode it has to sort after the
+// previous key of the dictionary.
+func (d *decoder) key(prev *string) (string, error) {
+	start := d.pos
+	key, err := d.string()
+	if err != nil {
+		return "", err
+	}
+	if d.strict && prev != nil && key <= *prev {
+		d.pos = start
+		if key == *prev {
+			return "", d.errorf("duplicate dictionary key %q", key)
+		}
+		return "", d.errorf("dictionary key %q out of order", key)
+	}
+	return key, nil
+}
+
+func (d *decoder) integer() (int64, error) {
+	end := bytes.IndexByte(d.data[d.pos:], 'e')
+	if end < 0 {
+		return 0, d.errorf("unterminated integer")
+	}
+	s := string(d.data[d.pos+1 : d.pos+end])
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || s == "-0" || s[0] == '+' || (len(s) > 1 && (s[0] == '0' || s[:2] == "-0")) {
+		return 0, d.errorf("invalid integer %q", s)
+	}
+	d.pos += end + 1
+	return n, nil
+}
+
+func (d *decoder) string() (string, error) {
+	colon := bytes.IndexByte(d.data[d.pos:], ':')
+	if colon <= 0 || !isDigits(d.data[d.pos:d.pos+colon]) {
+		return "", d.errorf("invalid string length")
+	}
+	length := d.data[d.pos : d.pos+colon]
+	n, err := strconv.Atoi(string(length))
+	if err != nil || (d.strict && len(length) > 1 && length[0] == '0') {
+		return "", d.errorf("invalid string length %q", length)
+	}
+	start := d.pos + colon + 1
+	if n > len(d.data)-start {
+		return "", d.errorf("string longer than the data")
+	}
+	d.pos = start + n
+	return string(d.data[start:d.pos]), nil
+}
+
+func isDigits(b []byte) bool {
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// field is a struct field as a dictionary entry.
+type field struct {
+	key       string
+	index     int
+	omitEmpty bool
+}
+
+// structFields holds the fields of struct types sorted by key.
+var structFields sync.Map
+
+type fieldList struct {
+	fields []field
+	err    error
+}
+
+func typeFields(t reflect.Type) ([]field, error) {
+	if cached, ok := structFields.Load(t); ok {
+		l := cached.(fieldList)
+		return l.fields, l.err
+	}
+	var l fieldList
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("bencode")
+		if !sf.IsExported() || tag == "-" {
+			continue
+		}
+		f := field{key: sf.Name, index: i}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name != "" {
+			f.key = name
+		}
+		f.omitEmpty = opts == "omitempty"
+		l.fields = append(l.fields, f)
+	}
+	sort.SliceStable(l.fields, func(i, j int) bool { return l.fields[i].key < l.fields[j].key })
+	for i := 1; i < len(l.fields); i++ {
+		if l.fields[i].key == l.fields[i-1].key {
+			l.err = fmt.Errorf("bencode: %s has two fields with the key %q", t, l.fields[i].key)
+		}
+	}
+	structFields.Store(t, l)
+	return l.fields, l.err
+}
+
+func lookupField(fields []field, key string) (field, bool) {
+	i := sort.Search(len(fields), func(i int) bool { return fields[i].key >= key })
+	if i < len(fields) && fields[i].key == key {
+		return fields[i], true
+	}
+	return field{}, false
+}
@@ -0,0 +1,88 @@
+package bencode
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    interface{}
+		wantErr bool
+	}{
+		{"integer", "i42e", int64(42), false},
+		{"negative integer", "i-42e", int64(-42), false},
+		{"zero", "i0e", int64(0), false},
+		{"string", "4:spam", "spam", false},
+		{"empty string", "0:", "", false},
+		{"list", "l4:spami42ee", []interface{}{"spam", int64(42)}, false},
+		{"dictionary", "d3:bar4:spam3:fooi42ee", map[string]interface{}{"bar": "spam", "foo": int64(42)}, false},
+		{"nested", "d4:listl1:ae4:dictd1:ki1eee", map[string]interface{}{
+			"list": []interface{}{"a"},
+			"dict": map[string]interface{}{"k": int64(1)},
+		}, false},
+		{"negative zero", "i-0e", nil, true},
+		{"leading zero", "i03e", nil, true},
+		{"unterminated integer", "i42", nil, true},
+		{"short string", "5:spam", nil, true},
+		{"unterminated list", "l4:spam", nil, true},
+		{"non string key", "di1ei2ee", nil, true},
+		{"trailing data", "i1ei2e", nil, true},
+		{"invalid type", "x", nil, true},
+		{"empty", "", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Decode() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		name    string
+		v       interface{}
+		want    string
+		wantErr bool
+	}{
+		{"integer", 42, "i42e", false},
+		{"string", "spam", "4:spam", false},
+		{"bytes", []byte{0, 1}, "2:\x00\x01", false},
+		{"strings", []string{"a", "bc"}, "l1:a2:bce", false},
+		{"sorted dictionary", map[string]interface{}{"foo": int64(42), "bar": []interface{}{"spam"}}, "d3:barl4:spame3:fooi42ee", false},
+		{"raw message", map[string]interface{}{"info": RawMessage("d1:ai1ee")}, "d4:infod1:ai1eee", false},
+		{"unsupported", 1.5, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Encode(tt.v)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Encode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Encode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodePrefix(t *testing.T) {
+	v, n, err := DecodePrefix([]byte("d8:msg_typei1ee\x00\x01\x02"))
+	if err != nil {
+		t.Fatalf("DecodePrefix() error = %v", err)
+	}
+	if want := map[string]interface{}{"msg_type": int64(1)}; !reflect.DeepEqual(v, want) || n != 15 {
+		t.Errorf("DecodePrefix() = %v, %d, want %v, 15", v, n, want)
+	}
+	if _, _, err := DecodePrefix([]byte("d8:msg_type")); err == nil {
+		t.Errorf("DecodePrefix() of a truncated value, error = nil")
+	}
+}
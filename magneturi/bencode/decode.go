@@ -0,0 +1,215 @@
+package bencode
+
+import (
+	"fmt"
+	"reflect"
+)
+
+var unmarshalerType = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+
+// Unmarshal decodes exactly one bencoded value from data into the value
+// v points to. Integers decode into integer types and bool (0 or 1),
+// strings into string, []byte and byte arrays of the same length, lists
+// into slices and arrays, dictionaries into structs and maps with
+// string keys, and any value into interface{} as Decode returns it.
+// Dictionary keys without a matching struct field are skipped.
+func Unmarshal(data []byte, v interface{}) error {
+	d := decoder{data: data, maxDepth: DefaultMaxDepth}
+	return d.unmarshalAll(v)
+}
+
+func (d *decoder) unmarshalAll(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("bencode: cannot unmarshal into %T, need a non-nil pointer", v)
+	}
+	if err := d.unmarshal(rv.Elem()); err != nil {
+		return err
+	}
+	if d.pos != len(d.data) {
+		return d.errorf("trailing data")
+	}
+	return nil
+}
+
+func (d *decoder) unmarshal(rv reflect.Value) error {
+	if d.pos >= len(d.data) {
+		return d.errorf("unexpected end of data")
+	}
+	for {
+		if rv.Kind() != reflect.Pointer && rv.CanAddr() && rv.Addr().Type().Implements(unmarshalerType) {
+			start := d.pos
+			if err := d.skip(); err != nil {
+				return err
+			}
+			return rv.Addr().Interface().(Unmarshaler).UnmarshalBencode(d.data[start:d.pos])
+		}
+		if rv.Kind() != reflect.Pointer {
+			break
+		}
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() == reflect.Interface && rv.NumMethod() == 0 {
+		v, err := d.value()
+		if err != nil {
+			return err
+		}
+		rv.Set(reflect.ValueOf(v))
+		return nil
+	}
+
+	start := d.pos
+	switch c := d.data[d.pos]; {
+	case c == 'i':
+		n, err := d.integer()
+		if err != nil {
+			return err
+		}
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if rv.OverflowInt(n) {
+				return d.rangeError(n, rv.Type(), start)
+			}
+			rv.SetInt(n)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if n < 0 || rv.OverflowUint(uint64(n)) {
+				return d.rangeError(n, rv.Type(), start)
+			}
+			rv.SetUint(uint64(n))
+		case reflect.Bool:
+			if n != 0 && n != 1 {
+				return d.rangeError(n, rv.Type(), start)
+			}
+			rv.SetBool(n == 1)
+		default:
+			return d.typeError("integer", rv.Type(), start)
+		}
+	case c >= '0' && c <= '9':
+		s, err := d.string()
+		if err != nil {
+			return err
+		}
+		switch {
+		case rv.Kind() == reflect.String:
+			rv.SetString(s)
+		case rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8:
+			rv.SetBytes([]byte(s))
+		case rv.Kind() == reflect.Array && rv.Type().Elem().Kind() == reflect.Uint8:
+			if len(s) != rv.Len() {
+				return fmt.Errorf("bencode: cannot unmarshal a string of %d bytes into %s at offset %d", len(s), rv.Type(), start)
+			}
+			reflect.Copy(rv, reflect.ValueOf([]byte(s)))
+		default:
+			return d.typeError("string", rv.Type(), start)
+		}
+	case c == 'l':
+		switch rv.Kind() {
+		case reflect.Slice, reflect.Array:
+		default:
+			return d.typeError("list", rv.Type(), start)
+		}
+		if err := d.enter(); err != nil {
+			return err
+		}
+		if rv.Kind() == reflect.Slice {
+			rv.Set(reflect.MakeSlice(rv.Type(), 0, 0))
+		}
+		for i := 0; ; i++ {
+			more, err := d.more("list")
+			if err != nil {
+				return err
+			}
+			if !more {
+				if rv.Kind() == reflect.Array {
+					for ; i < rv.Len(); i++ {
+						rv.Index(i).SetZero()
+					}
+				}
+				return nil
+			}
+			switch {
+			case rv.Kind() == reflect.Slice:
+				elem := reflect.New(rv.Type().Elem()).Elem()
+				if err := d.unmarshal(elem); err != nil {
+					return err
+				}
+				rv.Set(reflect.Append(rv, elem))
+			case i < rv.Len():
+				err = d.unmarshal(rv.Index(i))
+			default:
+				err = d.skip()
+			}
+			if err != nil {
+				return err
+			}
+		}
+	case c == 'd':
+		var fields []field
+		switch {
+		case rv.Kind() == reflect.Struct:
+			var err error
+			if fields, err = typeFields(rv.Type()); err != nil {
+				return err
+			}
+		case rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String:
+			if rv.IsNil() {
+				rv.Set(reflect.MakeMap(rv.Type()))
+			}
+		default:
+			return d.typeError("dictionary", rv.Type(), start)
+		}
+		if err := d.enter(); err != nil {
+			return err
+		}
+		for prev := (*string)(nil); ; {
+			more, err := d.more("dictionary")
+			if err != nil {
+				return err
+			}
+			if !more {
+				return nil
+			}
+			key, err := d.key(prev)
+			if err != nil {
+				return err
+			}
+			prev = &key
+			if rv.Kind() == reflect.Map {
+				elem := reflect.New(rv.Type().Elem()).Elem()
+				if err := d.unmarshal(elem); err != nil {
+					return err
+				}
+				rv.SetMapIndex(reflect.ValueOf(key).Convert(rv.Type().Key()), elem)
+				continue
+			}
+			if f, ok := lookupField(fields, key); ok {
+				err = d.unmarshal(rv.Field(f.index))
+			} else {
+				err = d.skip()
+			}
+			if err != nil {
+				return err
+			}
+		}
+	default:
+		return d.errorf("invalid value type %q", c)
+	}
+	return nil
+}
+
+// skip reads over one value, checking it like any other.
+func (d *decoder) skip() error {
+	_, err := d.value()
+	return err
+}
+
+func (d *decoder) typeError(what string, t reflect.Type, offset int) error {
+	return fmt.Errorf("bencode: cannot unmarshal a %s into %s at offset %d", what, t, offset)
+}
+
+func (d *decoder) rangeError(n int64, t reflect.Type, offset int) error {
+	return fmt.Errorf("bencode: integer %d out of range for %s at offset %d", n, t, offset)
+}
@@ -0,0 +1,178 @@
+package bencode
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testFile struct {
+	Length int64    `bencode:"length"`
+	Path   []string `bencode:"path"`
+	MD5    string   `bencode:"md5sum,omitempty"`
+}
+
+type testInfo struct {
+	Name        string     `bencode:"name"`
+	PieceLength int        `bencode:"piece length"`
+	Pieces      []byte     `bencode:"pieces"`
+	Private     bool       `bencode:"private,omitempty"`
+	Files       []testFile `bencode:"files,omitempty"`
+	Ignored     string     `bencode:"-"`
+}
+
+type testTorrent struct {
+	Announce string     `bencode:"announce"`
+	Info     RawMessage `bencode:"info"`
+	Parsed   *testInfo  `bencode:"-"`
+	Extra    interface{}
+}
+
+func TestUnmarshal(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		v       interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{"int", "i42e", new(int), 42, false},
+		{"uint8", "i255e", new(uint8), uint8(255), false},
+		{"uint8 overflow", "i256e", new(uint8), uint8(0), true},
+		{"negative uint", "i-1e", new(uint), uint(0), true},
+		{"bool", "i1e", new(bool), true, false},
+		{"bool out of range", "i2e", new(bool), false, true},
+		{"string", "4:spam", new(string), "spam", false},
+		{"bytes", "2:\x00\x01", new([]byte), []byte{0, 1}, false},
+		{"byte array", "4:abcd", new([4]byte), [4]byte{'a', 'b', 'c', 'd'}, false},
+		{"byte array length", "3:abc", new([4]byte), [4]byte{}, true},
+		{"list", "l1:a1:be", new([]string), []string{"a", "b"}, false},
+		{"empty list", "le", new([]string), []string{}, false},
+		{"array", "li1ei2ei3ee", new([2]int), [2]int{1, 2}, false},
+		{"map", "d1:ai1e1:bi2ee", new(map[string]int), map[string]int{"a": 1, "b": 2}, false},
+		{"pointer", "i7e", new(*int), func() *int { n := 7; return &n }(), false},
+		{"interface", "l1:ai1ee", new(interface{}), []interface{}{"a", int64(1)}, false},
+		{"raw message", "d1:ai1ee", new(RawMessage), RawMessage("d1:ai1ee"), false},
+		{
+			name: "struct",
+			data: "d5:filesld6:lengthi3e4:pathl1:a1:beee4:name4:test7:unknownli1ee12:piece lengthi16384e6:pieces0:7:privatei1ee",
+			v:    new(testInfo),
+			want: testInfo{
+				Name:        "test",
+				PieceLength: 16384,
+				Pieces:      []byte{},
+				Private:     true,
+				Files:       []testFile{{Length: 3, Path: []string{"a", "b"}}},
+			},
+		},
+		{"type mismatch", "4:spam", new(int), 0, true},
+		{"dictionary into slice", "de", new([]string), []string(nil), true},
+		{"non-empty interface", "i1e", new(error), error(nil), true},
+		{"trailing data", "i1ei2e", new(int), 1, true},
+		{"invalid", "i1", new(int), 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Unmarshal([]byte(tt.data), tt.v)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := reflect.ValueOf(tt.v).Elem().Interface(); !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unmarshal() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshal_notPointer(t *testing.T) {
+	var n int
+	if err := Unmarshal([]byte("i1e"), n); err == nil {
+		t.Errorf("Unmarshal() into a non-pointer, error = nil")
+	}
+	if err := Unmarshal([]byte("i1e"), (*int)(nil)); err == nil {
+		t.Errorf("Unmarshal() into a nil pointer, error = nil")
+	}
+}
+
+func TestUnmarshal_rawSpan(t *testing.T) {
+	// The info dictionary is not in canonical order, its raw bytes are
+	// kept as they are and not re-encoded.
+	info := "d4:name4:test6:pieces0:12:piece lengthi1ee"
+	data := "d8:announce17:http://a/announce4:info" + info + "5:Extrai9ee"
+	var torrent testTorrent
+	if err := Unmarshal([]byte(data), &torrent); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if string(torrent.Info) != info {
+		t.Errorf("Info = %q, want %q", torrent.Info, info)
+	}
+	if torrent.Announce != "http://a/announce" || torrent.Extra != int64(9) {
+		t.Errorf("Unmarshal() = %+v", torrent)
+	}
+	if err := Unmarshal(torrent.Info, &torrent.Parsed); err != nil || torrent.Parsed.PieceLength != 1 {
+		t.Errorf("Unmarshal() of the raw info = %+v, %v", torrent.Parsed, err)
+	}
+}
+
+func TestDecode_depthLimit(t *testing.T) {
+	deep := strings.Repeat("l", DefaultMaxDepth) + strings.Repeat("e", DefaultMaxDepth)
+	if _, err := Decode([]byte(deep)); err != nil {
+		t.Errorf("Decode() at the depth limit, error = %v", err)
+	}
+	tooDeep := "l" + deep + "e"
+	if _, err := Decode([]byte(tooDeep)); err == nil {
+		t.Errorf("Decode() beyond the depth limit, error = nil")
+	}
+	var v interface{}
+	if err := Unmarshal([]byte(tooDeep), &v); err == nil {
+		t.Errorf("Unmarshal() beyond the depth limit, error = nil")
+	}
+	if err := Unmarshal([]byte(strings.Repeat("d1:a", DefaultMaxDepth+1)), &v); err == nil {
+		t.Errorf("Unmarshal() of nested dictionaries beyond the depth limit, error = nil")
+	}
+}
+
+func FuzzDecode(f *testing.F) {
+	for _, seed := range []string{
+		"i42e", "i-1e", "4:spam", "0:", "le", "de",
+		"l4:spami42ee", "d3:bar4:spam3:fooi42ee", "d1:bi1e1:ai2ee",
+		"d4:infod6:lengthi3e4:name1:aee", "llleee", "04:spam",
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		v, err := Decode(data)
+		var raw RawMessage
+		if rawErr := Unmarshal(data, &raw); (rawErr != nil) != (err != nil) {
+			t.Fatalf("Decode() error = %v, Unmarshal() into RawMessage error = %v", err, rawErr)
+		}
+		if err != nil {
+			return
+		}
+		if !bytes.Equal(raw, data) {
+			t.Fatalf("RawMessage = %q, want %q", raw, data)
+		}
+		enc, err := Encode(v)
+		if err != nil {
+			t.Fatalf("Encode() error = %v", err)
+		}
+		// Encode writes canonical bencode, which decodes to the same
+		// value in strict mode.
+		d := NewDecoder(bytes.NewReader(enc))
+		d.Strict = true
+		var again interface{}
+		if err := d.Decode(&again); err != nil {
+			t.Fatalf("strict Decode() of %q error = %v", enc, err)
+		}
+		if !reflect.DeepEqual(v, again) {
+			t.Fatalf("round trip = %#v, want %#v", again, v)
+		}
+		// Canonical input is encoded exactly as it came.
+		d = NewDecoder(bytes.NewReader(data))
+		d.Strict = true
+		if d.Decode(new(interface{})) == nil && !bytes.Equal(enc, data) {
+			t.Fatalf("Encode() = %q, want the canonical input %q", enc, data)
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package bencode
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+var marshalerType = reflect.TypeOf((*Marshaler)(nil)).Elem()
+
+// Marshal bencodes v. Integer types and bool (as 0 or 1) become
+// integers, strings, []byte and byte arrays become strings, other
+// slices and arrays lists, and structs and maps with string keys
+// dictionaries with sorted keys. Struct fields tagged omitempty are
+// left out when they hold a zero or empty value. Nil pointers and
+// interfaces, floats and other types bencode cannot express are an
+// error.
+func Marshal(v interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	if err := encode(&b, reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func encode(b *bytes.Buffer, rv reflect.Value) error {
+	if !rv.IsValid() {
+		return fmt.Errorf("bencode: cannot marshal nil")
+	}
+	if rv.Kind() != reflect.Pointer && rv.CanAddr() && rv.Addr().Type().Implements(marshalerType) {
+		rv = rv.Addr()
+	}
+	if rv.Type().Implements(marshalerType) {
+		if (rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface) && rv.IsNil() {
+			return fmt.Errorf("bencode: cannot marshal nil %s", rv.Type())
+		}
+		raw, err := rv.Interface().(Marshaler).MarshalBencode()
+		if err != nil {
+			return err
+		}
+		b.Write(raw)
+		return nil
+	}
+	switch rv.Kind() {
+	case reflect.Bool:
+		if rv.Bool() {
+			b.WriteString("i1e")
+		} else {
+			b.WriteString("i0e")
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		b.WriteByte('i')
+		b.WriteString(strconv.FormatInt(rv.Int(), 10))
+		b.WriteByte('e')
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		b.WriteByte('i')
+		b.WriteString(strconv.FormatUint(rv.Uint(), 10))
+		b.WriteByte('e')
+	case reflect.String:
+		writeString(b, rv.String())
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			s := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(s), rv)
+			writeString(b, string(s))
+			return nil
+		}
+		b.WriteByte('l')
+		for i := 0; i < rv.Len(); i++ {
+			if err := encode(b, rv.Index(i)); err != nil {
+				return err
+			}
+		}
+		b.WriteByte('e')
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("bencode: unsupported map key type %s", rv.Type().Key())
+		}
+		keys := make([]string, 0, rv.Len())
+		for _, k := range rv.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sort.Strings(keys)
+		b.WriteByte('d')
+		for _, k := range keys {
+			writeString(b, k)
+			if err := encode(b, rv.MapIndex(reflect.ValueOf(k).Convert(rv.Type().Key()))); err != nil {
+				return err
+			}
+		}
+		b.WriteByte('e')
+	case reflect.Struct:
+		fields, err := typeFields(rv.Type())
+		if err != nil {
+			return err
+		}
+		b.WriteByte('d')
+		for _, f := range fields {
+			fv := rv.Field(f.index)
+			if f.omitEmpty && isEmpty(fv) {
+				continue
+			}
+			writeString(b, f.key)
+			if err := encode(b, fv); err != nil {
+				return err
+			}
+		}
+		b.WriteByte('e')
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return fmt.Errorf("bencode: cannot marshal nil %s", rv.Type())
+		}
+		return encode(b, rv.Elem())
+	default:
+		return fmt.Errorf("bencode: unsupported type %s", rv.Type())
+	}
+	return nil
+}
+
+func writeString(b *bytes.Buffer, s string) {
+	b.WriteString(strconv.Itoa(len(s)))
+	b.WriteByte(':')
+	b.WriteString(s)
+}
+
+func isEmpty(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return rv.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return rv.IsNil()
+	}
+	return rv.IsZero()
+}
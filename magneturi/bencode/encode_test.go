@@ -0,0 +1,62 @@
+package bencode
+
+import (
+	"errors"
+	"testing"
+)
+
+type marshalerFunc func() ([]byte, error)
+
+func (f marshalerFunc) MarshalBencode() ([]byte, error) { return f() }
+
+func TestMarshal(t *testing.T) {
+	n := 7
+	tests := []struct {
+		name    string
+		v       interface{}
+		want    string
+		wantErr bool
+	}{
+		{"bool", true, "i1e", false},
+		{"uint64", uint64(1 << 63), "i9223372036854775808e", false},
+		{"byte array", [3]byte{'a', 'b', 'c'}, "3:abc", false},
+		{"nil slice", []int(nil), "le", false},
+		{"pointer", &n, "i7e", false},
+		{"map", map[string][]int{"b": {1}, "a": nil}, "d1:ale1:bli1eee", false},
+		{
+			name: "struct",
+			v: testInfo{
+				Name:        "test",
+				PieceLength: 16384,
+				Pieces:      []byte("xy"),
+				Files:       []testFile{{Length: 3, Path: []string{"a"}, MD5: "m"}},
+				Ignored:     "ignored",
+			},
+			want: "d5:filesld6:lengthi3e6:md5sum1:m4:pathl1:aeee4:name4:test12:piece lengthi16384e6:pieces2:xye",
+		},
+		{"raw message field", testTorrent{Announce: "a", Info: RawMessage("de"), Extra: 1}, "d5:Extrai1e8:announce1:a4:infodee", false},
+		{"marshaler", marshalerFunc(func() ([]byte, error) { return []byte("i5e"), nil }), "i5e", false},
+		{"marshaler error", marshalerFunc(func() ([]byte, error) { return nil, errors.New("failed") }), "", true},
+		{"empty raw message", RawMessage(nil), "", true},
+		{"nil", nil, "", true},
+		{"nil pointer", (*int)(nil), "", true},
+		{"nil interface field", testTorrent{Info: RawMessage("de")}, "", true},
+		{"float", 1.5, "", true},
+		{"int map key", map[int]int{1: 1}, "", true},
+		{"duplicate keys", struct {
+			A int `bencode:"k"`
+			B int `bencode:"k"`
+		}{}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Marshal(tt.v)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Marshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Marshal() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package bencode
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// A Decoder reads bencoded values one after another from a stream. It
+// may buffer data beyond the value it decodes.
+type Decoder struct {
+	// Strict rejects values that are not in canonical form: dictionary
+	// keys that are repeated or not in sorted order, and string lengths
+	// with leading zeros.
+	Strict bool
+	// MaxDepth limits the nesting of lists and dictionaries,
+	// DefaultMaxDepth if zero.
+	MaxDepth int
+	// MaxSize limits the encoded size of a value in bytes,
+	// DefaultMaxSize if zero.
+	MaxSize int
+
+	r   *bufio.Reader
+	buf []byte
+}
+
+// NewDecoder returns a Decoder reading from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// Decode reads the next value from the stream and stores it in the
+// value v points to, as Unmarshal does. At the end of the stream it
+// returns io.EOF.
+func (d *Decoder) Decode(v interface{}) error {
+	d.buf = d.buf[:0]
+	if _, err := d.r.Peek(1); err != nil {
+		return err
+	}
+	if err := d.read(0); err != nil {
+		if errors.Is(err, io.EOF) {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	dec := decoder{data: d.buf, strict: d.Strict, maxDepth: d.maxDepth()}
+	return dec.unmarshalAll(v)
+}
+
+func (d *Decoder) maxDepth() int {
+	if d.MaxDepth > 0 {
+		return d.MaxDepth
+	}
+	return DefaultMaxDepth
+}
+
+func (d *Decoder) maxSize() int {
+	if d.MaxSize > 0 {
+		return d.MaxSize
+	}
+	return DefaultMaxSize
+}
+
+// read appends the bytes of one value to d.buf. It only frames the
+// value, the checks are left to the decoder running on d.buf.
+func (d *Decoder) read(depth int) error {
+	c, err := d.next()
+	if err != nil {
+		return err
+	}
+	switch {
+	case c == 'i':
+		_, err := d.readUntil('e', len("-9223372036854775808"))
+		return err
+	case c >= '0' && c <= '9':
+		length, err := d.readUntil(':', len("9223372036854775807")-1)
+		if err != nil {
+			return err
+		}
+		n, err := strconv.Atoi(string(c) + string(length))
+		if err != nil {
+			return d.errorf("invalid string length")
+		}
+		if n > d.maxSize()-len(d.buf) {
+			return d.errorf("value larger than %d bytes", d.maxSize())
+		}
+		return d.readN(n)
+	case c == 'l' || c == 'd':
+		if depth >= d.maxDepth() {
+			return d.errorf("nesting deeper than %d", d.maxDepth())
+		}
+		for {
+			next, err := d.r.Peek(1)
+			if err != nil {
+				return err
+			}
+			if next[0] == 'e' {
+				_, err := d.next()
+				return err
+			}
+			if err := d.read(depth + 1); err != nil {
+				return err
+			}
+		}
+	default:
+		return d.errorf("invalid value type %q", c)
+	}
+}
+
+func (d *Decoder) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("bencode: "+format+" at offset %d", append(args, len(d.buf))...)
+}
+
+func (d *Decoder) next() (byte, error) {
+	if len(d.buf) >= d.maxSize() {
+		return 0, d.errorf("value larger than %d bytes", d.maxSize())
+	}
+	c, err := d.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	d.buf = append(d.buf, c)
+	return c, nil
+}
+
+// readUntil reads up to and including delim, which has to come within
+// max bytes, and returns what came before it.
+func (d *Decoder) readUntil(delim byte, max int) ([]byte, error) {
+	start := len(d.buf)
+	for i := 0; i <= max; i++ {
+		c, err := d.next()
+		if err != nil {
+			return nil, err
+		}
+		if c == delim {
+			return d.buf[start : len(d.buf)-1], nil
+		}
+	}
+	return nil, d.errorf("missing %q", delim)
+}
+
+// readN reads n bytes in chunks, so a length that promises more than
+// the stream holds does not allocate it up front.
+func (d *Decoder) readN(n int) error {
+	const chunk = 32 << 10
+	for n > 0 {
+		k := min(n, chunk)
+		start := len(d.buf)
+		d.buf = append(d.buf, make([]byte, k)...)
+		if _, err := io.ReadFull(d.r, d.buf[start:]); err != nil {
+			return err
+		}
+		n -= k
+	}
+	return nil
+}
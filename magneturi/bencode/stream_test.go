@@ -0,0 +1,76 @@
+package bencode
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecoder(t *testing.T) {
+	d := NewDecoder(strings.NewReader("i1e4:spamd1:ai1eeli2ee"))
+	var got []interface{}
+	for {
+		var v interface{}
+		err := d.Decode(&v)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+		got = append(got, v)
+	}
+	want := []interface{}{int64(1), "spam", map[string]interface{}{"a": int64(1)}, []interface{}{int64(2)}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Decode() = %#v, want %#v", got, want)
+	}
+}
+
+func TestDecoder_errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		strict bool
+		limits Decoder
+		want   error
+	}{
+		{name: "truncated", data: "d1:ai1e", want: io.ErrUnexpectedEOF},
+		{name: "truncated string", data: "10:abc", want: io.ErrUnexpectedEOF},
+		{name: "invalid type", data: "x"},
+		{name: "overlong integer", data: "i" + strings.Repeat("1", 30) + "e"},
+		{name: "unsorted keys", data: "d1:bi1e1:ai2ee", strict: true},
+		{name: "duplicate keys", data: "d1:ai1e1:ai2ee", strict: true},
+		{name: "leading zero length", data: "04:spam", strict: true},
+		{name: "string over the size limit", data: "2000000000:", limits: Decoder{MaxSize: 1 << 20}},
+		{name: "value over the size limit", data: "l" + strings.Repeat("i1e", 10) + "e", limits: Decoder{MaxSize: 16}},
+		{name: "depth limit", data: "llllee", limits: Decoder{MaxDepth: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDecoder(strings.NewReader(tt.data))
+			d.Strict = tt.strict
+			d.MaxDepth, d.MaxSize = tt.limits.MaxDepth, tt.limits.MaxSize
+			var v interface{}
+			err := d.Decode(&v)
+			if err == nil {
+				t.Fatalf("Decode() = %#v, want an error", v)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("Decode() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecoder_lenient(t *testing.T) {
+	d := NewDecoder(strings.NewReader("d1:bi1e1:ai2e1:ai3ee"))
+	var v map[string]int
+	if err := d.Decode(&v); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if want := map[string]int{"a": 3, "b": 1}; !reflect.DeepEqual(v, want) {
+		t.Errorf("Decode() = %v, want %v", v, want)
+	}
+}
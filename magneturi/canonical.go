@@ -0,0 +1,130 @@
+package magneturi
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// canonicalOrder is the order of the prefixes in a canonical link.
+var canonicalOrder = []string{"xt", "xl", "dn", "kt", "mt", "tr", "ws", "as", "xs", "x."}
+
+// ErrConflict is returned by Merge for links that are not for the same
+// content.
+var ErrConflict = errors.New("the Magnet URIs are for different content")
+
+// Canonical returns a copy of the link in canonical form, so that
+// links carrying the same information serialize the same way:
+//   - exact topics are written as urn:<namespace>:<hash> with a lower
+//     case namespace, btih hashes as lower case hex
+//   - trackers that are duplicates as defined by Tracker.Key and
+//     repeated parameters are dropped
+//   - parameters are grouped by prefix in the order xt, xl, dn, kt, mt,
+//     tr, ws, as, xs, x. and keep their order within a prefix
+func (m *MagnetURI) Canonical() *MagnetURI {
+	c := &MagnetURI{}
+	seen := map[param]bool{}
+	for _, p := range m.params {
+		if p.prefix == "xt" {
+			p.value = canonicalTopic(p.index, p.value)
+		}
+		if !seen[p] {
+			seen[p] = true
+			c.params = append(c.params, p)
+		}
+	}
+	c.RemoveDuplicateTrackers()
+	rank := map[string]int{}
+	for i, prefix := range canonicalOrder {
+		rank[prefix] = i
+	}
+	sort.SliceStable(c.params, func(i, j int) bool {
+		return rank[c.params[i].prefix] < rank[c.params[j].prefix]
+	})
+	return c
+}
+
+func canonicalTopic(index, value string) string {
+	t, err := parseExactTopic(index, value)
+	if err != nil {
+		return value
+	}
+	if t.Namespace == string(BTIH) {
+		if h, err := ParseInfoHash(t.Hash); err == nil {
+			t.Hash = h.String()
+		}
+	}
+	return t.String()
+}
+
+// Merge combines links for the same content into one canonical link.
+// Each link has to share an exact topic with the links before it and
+// must not disagree with them on a hash of the same namespace or on
+// the exact length, otherwise the error wraps ErrConflict. Hashes are
+// compared decoded, a hash written in hex and in base32 is the same
+// and is kept once. Of several display names the first one is kept.
+func Merge(links ...*MagnetURI) (*MagnetURI, error) {
+	merged := &MagnetURI{}
+	known := map[string]map[string]bool{}
+	for i, link := range links {
+		link = link.Canonical()
+		topics := link.ExactTopics()
+		shared := i == 0
+		for _, t := range topics {
+			hashes, ok := known[t.Namespace]
+			switch {
+			case !ok:
+			case hashes[t.hashKey()]:
+				shared = true
+			default:
+				return nil, fmt.Errorf("%w: link %d has the exact topic %q", ErrConflict, i+1, t.String())
+			}
+		}
+		if !shared {
+			return nil, fmt.Errorf("%w: link %d has no exact topic in common with the links before it", ErrConflict, i+1)
+		}
+		for _, t := range topics {
+			if known[t.Namespace] == nil {
+				known[t.Namespace] = map[string]bool{}
+			}
+			known[t.Namespace][t.hashKey()] = true
+		}
+		if xl, ok := link.firstValue("xl"); ok {
+			if mergedXL, ok := merged.firstValue("xl"); ok && mergedXL != xl {
+				return nil, fmt.Errorf("%w: exact lengths %s and %s", ErrConflict, mergedXL, xl)
+			}
+		}
+		for _, p := range link.params {
+			if p.prefix == "dn" && merged.hasParam("dn", p.index) {
+				continue
+			}
+			if t, err := parseExactTopic(p.index, p.value); err == nil && p.prefix == "xt" {
+				if merged.hasTopicHash(t) {
+					continue
+				}
+			}
+			merged.params = append(merged.params, p)
+		}
+	}
+	return merged.Canonical(), nil
+}
+
+// hasTopicHash reports whether the link has an exact topic with the
+// hash of t in the same namespace.
+func (m *MagnetURI) hasTopicHash(t ExactTopic) bool {
+	for _, mt := range m.ExactTopics() {
+		if mt.Namespace == t.Namespace && mt.hashKey() == t.hashKey() {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MagnetURI) hasParam(prefix, index string) bool {
+	for _, p := range m.params {
+		if p.prefix == prefix && p.index == index {
+			return true
+		}
+	}
+	return false
+}
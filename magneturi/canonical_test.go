@@ -0,0 +1,114 @@
+package magneturi
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMagnetURI_Canonical(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{
+			name: "empty",
+			raw:  "magnet:?",
+			want: "magnet:?",
+		},
+		{
+			name: "ordered by prefix",
+			raw:  "magnet:?x.foo=bar&tr=udp%3A%2F%2Fa%3A80&dn=name&xt=urn:ed2k:354B15E68FB8F36D7CD88FF94116CDC1&xl=10",
+			want: "magnet:?xt=urn:ed2k:354B15E68FB8F36D7CD88FF94116CDC1&xl=10&dn=name&tr=udp%3A%2F%2Fa%3A80&x.foo=bar",
+		},
+		{
+			name: "btih as lower case hex",
+			raw:  "magnet:?xt=URN:BTIH:QHQXPYWMACKDWKP47RRVIV7VOURXFE5Q",
+			want: "magnet:?xt=urn:btih:81e177e2cc00943b29fcfc635457f575237293b0",
+		},
+		{
+			name: "duplicates dropped",
+			raw:  "magnet:?tr=udp%3A%2F%2Fa%3A80&tr=udp://a:80/&dn=x&dn=x&tr.1=http://b",
+			want: "magnet:?dn=x&tr=udp%3A%2F%2Fa%3A80&tr.1=http://b",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := MustParse(tt.raw)
+			if got := m.Canonical().String(); got != tt.want {
+				t.Errorf("Canonical() = %s, want %s", got, tt.want)
+			}
+			if m.String() != tt.raw {
+				t.Errorf("Canonical() changed the link to %s", m)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	const btih = "urn:btih:81e177e2cc00943b29fcfc635457f575237293b0"
+	tests := []struct {
+		name    string
+		raws    []string
+		want    string
+		wantErr error
+	}{
+		{
+			name: "trackers and names",
+			raws: []string{
+				"magnet:?xt=" + btih + "&dn=first&tr=http://a",
+				"magnet:?xt=urn:btih:QHQXPYWMACKDWKP47RRVIV7VOURXFE5Q&dn=second&tr=http://b&tr=http://a/",
+			},
+			want: "magnet:?xt=" + btih + "&dn=first&tr=http://a&tr=http://b",
+		},
+		{
+			name: "other topics",
+			raws: []string{
+				"magnet:?xt.1=" + btih + "&xl=10",
+				"magnet:?xt.1=" + btih + "&xt.2=urn:ed2k:354B15E68FB8F36D7CD88FF94116CDC1&xl=10",
+			},
+			want: "magnet:?xt.1=" + btih + "&xt.2=urn:ed2k:354B15E68FB8F36D7CD88FF94116CDC1&xl=10",
+		},
+		{
+			name: "same hashes in other encodings",
+			raws: []string{
+				"magnet:?xt.1=" + btih + "&xt.2=urn:sha1:QHQXPYWMACKDWKP47RRVIV7VOURXFE5Q&xt.3=urn:ed2k:354b15e68fb8f36d7cd88ff94116cdc1",
+				"magnet:?xt=urn:sha1:81e177e2cc00943b29fcfc635457f575237293b0&xt=urn:ed2k:354B15E68FB8F36D7CD88FF94116CDC1&dn=a",
+			},
+			want: "magnet:?xt.1=" + btih + "&xt.2=urn:sha1:QHQXPYWMACKDWKP47RRVIV7VOURXFE5Q&xt.3=urn:ed2k:354b15e68fb8f36d7cd88ff94116cdc1&dn=a",
+		},
+		{
+			name: "different hash",
+			raws: []string{
+				"magnet:?xt=" + btih,
+				"magnet:?xt=" + btih + "&xt=urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a",
+			},
+			wantErr: ErrConflict,
+		},
+		{
+			name:    "no topic in common",
+			raws:    []string{"magnet:?xt=" + btih, "magnet:?xt=urn:ed2k:354B15E68FB8F36D7CD88FF94116CDC1"},
+			wantErr: ErrConflict,
+		},
+		{
+			name:    "different length",
+			raws:    []string{"magnet:?xt=" + btih + "&xl=10", "magnet:?xt=" + btih + "&xl=11"},
+			wantErr: ErrConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var links []*MagnetURI
+			for _, raw := range tt.raws {
+				links = append(links, MustParse(raw))
+			}
+			got, err := Merge(links...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Merge() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got.String() != tt.want {
+				t.Errorf("Merge() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package magneturi
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// compactVersion is the first byte of a compact token's payload.
+const compactVersion = 1
+
+// Record kinds of the compact form. Each record is the kind, the
+// parameter index as a length prefixed string and a payload.
+const (
+	compactParam        = iota // prefix and value as length prefixed strings
+	compactBTIH                // 20 byte info-hash
+	compactBTMH                // 32 byte SHA-256 of a sha2-256 multihash
+	compactTracker             // tracker dictionary index, value query escaped
+	compactTrackerPlain        // tracker dictionary index, value as is
+	compactDisplayName         // name, value query escaped
+	compactExactLength         // uvarint
+	compactKinds
+)
+
+// compactTrackers are the well-known trackers the compact form codes
+// as their position. Tokens depend on the order, so trackers can only
+// be appended.
+var compactTrackers = []string{
+	"udp://tracker.opentrackr.org:1337/announce",
+	"udp://open.stealth.si:80/announce",
+	"udp://tracker.torrent.eu.org:451/announce",
+	"udp://exodus.desync.com:6969/announce",
+	"udp://tracker.openbittorrent.com:6969/announce",
+	"udp://tracker.openbittorrent.com:80/announce",
+	"udp://open.demonii.com:1337/announce",
+	"udp://tracker.moeking.me:6969/announce",
+	"udp://explodie.org:6969/announce",
+	"udp://tracker.tiny-vps.com:6969/announce",
+	"udp://tracker.theoks.net:6969/announce",
+	"udp://tracker.dler.org:6969/announce",
+	"udp://opentracker.i2p.rocks:6969/announce",
+	"udp://tracker1.bt.moack.co.kr:80/announce",
+	"udp://tracker.internetwarriors.net:1337/announce",
+	"udp://tracker.leechers-paradise.org:6969/announce",
+	"udp://tracker.coppersurfer.tk:6969/announce",
+	"udp://9.rarbg.to:2710/announce",
+	"udp://9.rarbg.me:2710/announce",
+	"udp://tracker.cyberia.is:6969/announce",
+	"udp://ipv4.tracker.harry.lu:80/announce",
+	"udp://tracker.zer0day.to:1337/announce",
+	"udp://p4p.arenabg.com:1337/announce",
+	"udp://tracker.pirateparty.gr:6969/announce",
+	"http://tracker.opentrackr.org:1337/announce",
+	"https://tracker.opentrackr.org:443/announce",
+	"http://tracker.openbittorrent.com:80/announce",
+	"https://opentracker.i2p.rocks:443/announce",
+	"wss://tracker.openwebtorrent.com",
+	"wss://tracker.btorrent.xyz",
+	"wss://tracker.webtorrent.dev",
+	"wss://tracker.files.fm:7073/announce",
+}
+
+var compactTrackerIndex = func() map[string]int {
+	index := map[string]int{}
+	for i, t := range compactTrackers {
+		index[t] = i
+	}
+	return index
+}()
+
+// Token prefixes, from multibase: base64url and lower case base32, both
+// without padding.
+const (
+	compactBase64 = 'u'
+	compactBase32 = 'b'
+)
+
+var compactBase32Encoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// ErrInvalidCompact is wrapped by the errors of ExpandCompact.
+var ErrInvalidCompact = errors.New("invalid compact token")
+
+// Compact returns the link as a short base64url token for channels
+// with a length limit. It packs info-hashes as bytes, well-known
+// trackers as a dictionary index and the display name unescaped, the
+// other parameters are kept as they are. ExpandCompact returns the
+// canonical form of the link from it.
+func (m *MagnetURI) Compact() string {
+	return string(compactBase64) + base64.RawURLEncoding.EncodeToString(m.compactPayload())
+}
+
+// CompactBase32 is Compact with a lower case base32 token, about a
+// fifth longer but safe where case is not kept or only letters and
+// digits are allowed.
+func (m *MagnetURI) CompactBase32() string {
+	return string(compactBase32) + compactBase32Encoding.EncodeToString(m.compactPayload())
+}
+
+func (m *MagnetURI) compactPayload() []byte {
+	b := []byte{compactVersion}
+	for _, p := range m.Canonical().params {
+		b = appendCompactParam(b, p)
+	}
+	return b
+}
+
+func appendCompactParam(b []byte, p param) []byte {
+	record := func(kind int) []byte {
+		b = append(b, byte(kind))
+		return appendCompactString(b, p.index)
+	}
+	switch p.prefix {
+	case "xt":
+		if h, ok := strings.CutPrefix(p.value, "urn:btih:"); ok && len(h) == 40 && h == strings.ToLower(h) {
+			if raw, err := hex.DecodeString(h); err == nil {
+				return append(record(compactBTIH), raw...)
+			}
+		}
+		if h, ok := strings.CutPrefix(p.value, "urn:btmh:1220"); ok && len(h) == 64 && h == strings.ToLower(h) {
+			if raw, err := hex.DecodeString(h); err == nil {
+				return append(record(compactBTMH), raw...)
+			}
+		}
+	case "tr":
+		if i, ok := compactTrackerIndex[p.value]; ok {
+			return binary.AppendUvarint(record(compactTrackerPlain), uint64(i))
+		}
+		if v, err := url.QueryUnescape(p.value); err == nil && url.QueryEscape(v) == p.value {
+			if i, ok := compactTrackerIndex[v]; ok {
+				return binary.AppendUvarint(record(compactTracker), uint64(i))
+			}
+		}
+	case "dn":
+		if v, err := url.QueryUnescape(p.value); err == nil && url.QueryEscape(v) == p.value {
+			return appendCompactString(record(compactDisplayName), v)
+		}
+	case "xl":
+		if n, err := strconv.ParseUint(p.value, 10, 64); err == nil && strconv.FormatUint(n, 10) == p.value {
+			return binary.AppendUvarint(record(compactExactLength), n)
+		}
+	}
+	b = appendCompactString(record(compactParam), p.prefix)
+	return appendCompactString(b, p.value)
+}
+
+func appendCompactString(b []byte, s string) []byte {
+	b = binary.AppendUvarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+// ExpandCompact returns the link of a token made by Compact or
+// CompactBase32, in canonical form.
+func ExpandCompact(token string) (*MagnetURI, error) {
+	if token == "" {
+		return nil, fmt.Errorf("%w: empty", ErrInvalidCompact)
+	}
+	var payload []byte
+	var err error
+	switch token[0] {
+	case compactBase64:
+		payload, err = base64.RawURLEncoding.DecodeString(token[1:])
+	case compactBase32:
+		payload, err = compactBase32Encoding.DecodeString(strings.ToLower(token[1:]))
+	default:
+		return nil, fmt.Errorf("%w: unknown encoding %q", ErrInvalidCompact, token[:1])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCompact, err)
+	}
+	if len(payload) == 0 || payload[0] != compactVersion {
+		return nil, fmt.Errorf("%w: unsupported version", ErrInvalidCompact)
+	}
+	r := compactReader{b: payload[1:]}
+	m := &MagnetURI{}
+	for len(r.b) > 0 {
+		p, err := r.param()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCompact, err)
+		}
+		m.params = append(m.params, p)
+	}
+	// Parsing the assembled link rejects prefixes and values that do
+	// not make a valid link.
+	link, err := Parse(m.String(), false)
+	if err != nil || link.String() != m.String() {
+		return nil, fmt.Errorf("%w: not a valid link: %q", ErrInvalidCompact, m.String())
+	}
+	return link.Canonical(), nil
+}
+
+type compactReader struct {
+	b []byte
+}
+
+var errCompactTruncated = errors.New("truncated")
+
+func (r *compactReader) uvarint() (uint64, error) {
+	n, size := binary.Uvarint(r.b)
+	if size <= 0 {
+		return 0, errCompactTruncated
+	}
+	r.b = r.b[size:]
+	return n, nil
+}
+
+func (r *compactReader) bytes(n uint64) ([]byte, error) {
+	if uint64(len(r.b)) < n {
+		return nil, errCompactTruncated
+	}
+	b := r.b[:n]
+	r.b = r.b[n:]
+	return b, nil
+}
+
+func (r *compactReader) string() (string, error) {
+	n, err := r.uvarint()
+	if err != nil {
+		return "", err
+	}
+	b, err := r.bytes(n)
+	return string(b), err
+}
+
+func (r *compactReader) param() (param, error) {
+	kind := r.b[0]
+	r.b = r.b[1:]
+	if kind >= compactKinds {
+		return param{}, fmt.Errorf("unknown record kind %d", kind)
+	}
+	index, err := r.string()
+	if err != nil {
+		return param{}, err
+	}
+	p := param{index: index}
+	switch kind {
+	case compactParam:
+		if p.prefix, err = r.string(); err == nil {
+			p.value, err = r.string()
+		}
+	case compactBTIH, compactBTMH:
+		size, urn := uint64(20), "urn:btih:"
+		if kind == compactBTMH {
+			size, urn = 32, "urn:btmh:1220"
+		}
+		var h []byte
+		if h, err = r.bytes(size); err == nil {
+			p.prefix, p.value = "xt", urn+hex.EncodeToString(h)
+		}
+	case compactTracker, compactTrackerPlain:
+		var i uint64
+		if i, err = r.uvarint(); err == nil {
+			if i >= uint64(len(compactTrackers)) {
+				return param{}, fmt.Errorf("unknown tracker %d", i)
+			}
+			p.prefix, p.value = "tr", compactTrackers[i]
+			if kind == compactTracker {
+				p.value = url.QueryEscape(p.value)
+			}
+		}
+	case compactDisplayName:
+		if p.value, err = r.string(); err == nil {
+			p.prefix, p.value = "dn", url.QueryEscape(p.value)
+		}
+	case compactExactLength:
+		var n uint64
+		if n, err = r.uvarint(); err == nil {
+			p.prefix, p.value = "xl", strconv.FormatUint(n, 10)
+		}
+	}
+	return p, err
+}
@@ -0,0 +1,85 @@
+package magneturi
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCompactRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"empty", "magnet:?"},
+		{"info-hash only", "magnet:?xt=urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a"},
+		{"base32 info-hash", "magnet:?xt=urn:btih:YEX6DQDLXISUVHOJ6UM3GNNKPQJWPKEK&dn=a"},
+		{
+			"well-known trackers",
+			"magnet:?xt=urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a&dn=Example+File.iso&xl=1048576" +
+				"&tr=udp%3A%2F%2Ftracker.opentrackr.org%3A1337%2Fannounce&tr=udp://open.stealth.si:80/announce" +
+				"&tr.2=wss%3A%2F%2Ftracker.openwebtorrent.com&tr=udp%3A%2F%2Ftracker.example.org%3A6969",
+		},
+		{
+			"hybrid",
+			"magnet:?xt=urn:btih:631a31dd0a46257d5078c0dee4e66e26f73e42ac&xt=urn:btmh:1220d8dd32ac93357c368556af3ac1d95c9d76bd0dff6fa9833ecdac3d53134efabb&dn=bittorrent-v1-v2-hybrid-test",
+		},
+		{
+			"other parameters",
+			"magnet:?xt.1=urn:ed2k:354B15E68FB8F36D7CD88FF94116CDC1&xt.2=urn:tree:tiger:7N5OAMRNGMSSEUE3ORHOKWN4WWIQ5X4EBOOTLJY&xl=10826029&dn=mediawiki-1.15.1.tar.gz" +
+				"&as=http%3A%2F%2Fdownload.wikimedia.org%2Fmediawiki%2F1.15%2Fmediawiki-1.15.1.tar.gz&xs=dchub://example.org&x.Moz11=test",
+		},
+		{"not canonical", "magnet:?x.pe=1.2.3.4:5&tr=http://a/announce&dn=a%20b&tr=http://a/announce&xl=007&xt.1=urn:BTIH:YEX6DQDLXISUVHOJ6UM3GNNKPQJWPKEK"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := MustParse(tt.raw)
+			want := m.Canonical().String()
+			base32 := m.CompactBase32()
+			for _, token := range []string{m.Compact(), base32, "b" + strings.ToUpper(base32[1:])} {
+				got, err := ExpandCompact(token)
+				if err != nil {
+					t.Fatalf("ExpandCompact(%q) error = %v", token, err)
+				}
+				if got.String() != want {
+					t.Errorf("ExpandCompact(%q) = %s, want %s", token, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCompactSize(t *testing.T) {
+	m := MustParse("magnet:?xt=urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a&dn=Example+File.iso" +
+		"&tr=udp%3A%2F%2Ftracker.opentrackr.org%3A1337%2Fannounce&tr=udp%3A%2F%2Fopen.stealth.si%3A80%2Fannounce" +
+		"&tr=udp%3A%2F%2Ftracker.torrent.eu.org%3A451%2Fannounce&tr=udp%3A%2F%2Fexodus.desync.com%3A6969%2Fannounce")
+	// version, 20 byte hash, 4 trackers of 3 bytes and the name
+	if got, want := len(m.Compact()), 1+(1+22+4*3+18+2)*4/3+1; got > want {
+		t.Errorf("len(Compact()) = %d, want at most %d", got, want)
+	}
+}
+
+func TestExpandCompactErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"empty", ""},
+		{"unknown encoding", "zAQ"},
+		{"bad base64", "u!!"},
+		{"no payload", "u"},
+		{"unknown version", "uAg"},
+		{"unknown kind", "uAQc"},
+		{"truncated hash", "uAQEAAQI"},
+		{"unknown tracker", "uAQQAfw"},
+		{"invalid prefix", "uAQAAAnp6AWE"},
+		{"ampersand in value", "uAQAAAmRuA2EmYg"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ExpandCompact(tt.token); !errors.Is(err, ErrInvalidCompact) {
+				t.Errorf("ExpandCompact(%q) error = %v, want ErrInvalidCompact", tt.token, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,389 @@
+// Package dht finds peers for magnet links without trackers with an
+// iterative get_peers lookup in the BitTorrent Mainline DHT (BEP 5).
+// It is a client only, it does not answer queries nor keep a routing
+// table between lookups.
+package dht
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/nmmh/magneturi/magneturi"
+	"github.com/nmmh/magneturi/magneturi/bencode"
+)
+
+// DefaultBootstrap are well known public DHT routers.
+var DefaultBootstrap = []string{
+	"router.bittorrent.com:6881",
+	"dht.transmissionbt.com:6881",
+	"router.utorrent.com:6881",
+}
+
+const (
+	// DefaultTimeout is how long a single query waits for its answer.
+	DefaultTimeout = 2 * time.Second
+	// DefaultAlpha is how many queries are in flight at once.
+	DefaultAlpha = 3
+	// DefaultK is the bucket size, the lookup ends once the K nodes
+	// closest to the info-hash have answered.
+	DefaultK = 8
+
+	compactNodeLen = 26
+)
+
+// Client looks up peers, the zero value is ready to use.
+type Client struct {
+	// Bootstrap are the host:port of the nodes the lookup starts
+	// from, DefaultBootstrap if empty.
+	Bootstrap []string
+	// NodeID identifies us, a random one is used if it is zero.
+	NodeID [20]byte
+	// Timeout limits a single query, DefaultTimeout if 0.
+	Timeout time.Duration
+	// Alpha and K tune the lookup, DefaultAlpha and DefaultK if 0.
+	Alpha int
+	K     int
+	// MaxPeers ends the lookup early once that many peers are known,
+	// 0 means the lookup runs to completion.
+	MaxPeers int
+}
+
+// GetPeers looks up the peers of the first btih info-hash of m.
+func (c *Client) GetPeers(ctx context.Context, m *magneturi.MagnetURI) ([]netip.AddrPort, error) {
+	hashes := m.InfoHashes()
+	if len(hashes) == 0 {
+		return nil, fmt.Errorf("dht: magnet uri has no btih exact topic")
+	}
+	return c.GetPeersFor(ctx, hashes[0])
+}
+
+// node is a DHT node, the zero id stands for a bootstrap node whose id
+// is not known yet.
+type node struct {
+	id   [20]byte
+	addr netip.AddrPort
+}
+
+// GetPeersFor runs an iterative get_peers lookup for infoHash. It
+// returns the peers found even when the lookup is cut short by ctx.
+func (c *Client) GetPeersFor(ctx context.Context, infoHash magneturi.InfoHash) ([]netip.AddrPort, error) {
+	conn, err := net.ListenUDP("udp", nil)
+	if err != nil {
+		return nil, err
+	}
+	s := &session{
+		conn:    conn,
+		pending: map[string]chan map[string]interface{}{},
+	}
+	defer s.close()
+	go s.read()
+
+	bootstrap := c.Bootstrap
+	if len(bootstrap) == 0 {
+		bootstrap = DefaultBootstrap
+	}
+	var candidates []node
+	for _, b := range bootstrap {
+		addrs, err := resolve(ctx, b)
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			candidates = append(candidates, node{addr: a})
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("dht: no bootstrap node could be resolved")
+	}
+
+	l := &lookup{
+		client:   c,
+		s:        s,
+		target:   infoHash,
+		id:       c.nodeID(),
+		queried:  map[netip.AddrPort]bool{},
+		answered: map[[20]byte]node{},
+		seen:     map[netip.AddrPort]bool{},
+	}
+	return l.run(ctx, candidates)
+}
+
+func (c *Client) nodeID() [20]byte {
+	if c.NodeID != ([20]byte{}) {
+		return c.NodeID
+	}
+	var id [20]byte
+	rand.Read(id[:])
+	return id
+}
+
+func resolve(ctx context.Context, hostport string) ([]netip.AddrPort, error) {
+	if ap, err := netip.ParseAddrPort(hostport); err == nil {
+		return []netip.AddrPort{ap}, nil
+	}
+	host, port, err := net.SplitHostPort(hostport)
+	if err != nil {
+		return nil, err
+	}
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip4", host)
+	if err != nil {
+		return nil, err
+	}
+	p, err := net.LookupPort("udp", port)
+	if err != nil {
+		return nil, err
+	}
+	var addrs []netip.AddrPort
+	for _, ip := range ips {
+		addrs = append(addrs, netip.AddrPortFrom(ip.Unmap(), uint16(p)))
+	}
+	return addrs, nil
+}
+
+type lookup struct {
+	client *Client
+	s      *session
+	target [20]byte
+	id     [20]byte
+
+	mu         sync.Mutex
+	candidates []node
+	queried    map[netip.AddrPort]bool
+	answered   map[[20]byte]node
+	peers      []netip.AddrPort
+	seen       map[netip.AddrPort]bool
+}
+
+func (l *lookup) run(ctx context.Context, bootstrap []node) ([]netip.AddrPort, error) {
+	alpha, k := l.client.Alpha, l.client.K
+	if alpha <= 0 {
+		alpha = DefaultAlpha
+	}
+	if k <= 0 {
+		k = DefaultK
+	}
+	l.candidates = bootstrap
+	done := make(chan struct{}, alpha)
+	inFlight := 0
+	for {
+		l.mu.Lock()
+		enough := l.client.MaxPeers > 0 && len(l.peers) >= l.client.MaxPeers
+		var next []node
+		if !enough && !l.converged(k) {
+			next = l.nextCandidates(alpha - inFlight)
+		}
+		l.mu.Unlock()
+		for _, n := range next {
+			inFlight++
+			go func(n node) {
+				l.query(ctx, n)
+				done <- struct{}{}
+			}(n)
+		}
+		if inFlight == 0 {
+			break
+		}
+		select {
+		case <-done:
+			inFlight--
+		case <-ctx.Done():
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			return append([]netip.AddrPort{}, l.peers...), ctx.Err()
+		}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.answered) == 0 {
+		return nil, errors.New("dht: no node answered")
+	}
+	return l.peers, nil
+}
+
+// converged reports whether the k closest nodes known have answered
+// and no unqueried candidate is closer than the farthest of them.
+func (l *lookup) converged(k int) bool {
+	if len(l.answered) < k {
+		return false
+	}
+	closest := make([]node, 0, len(l.answered))
+	for _, n := range l.answered {
+		closest = append(closest, n)
+	}
+	l.sortByDistance(closest)
+	farthest := closest[k-1]
+	for _, n := range l.candidates {
+		if !l.queried[n.addr] && l.closer(n.id, farthest.id) {
+			return false
+		}
+	}
+	return true
+}
+
+// nextCandidates takes up to n of the closest unqueried candidates.
+func (l *lookup) nextCandidates(n int) []node {
+	l.sortByDistance(l.candidates)
+	var next []node
+	for _, c := range l.candidates {
+		if len(next) >= n {
+			break
+		}
+		if !l.queried[c.addr] {
+			l.queried[c.addr] = true
+			next = append(next, c)
+		}
+	}
+	return next
+}
+
+func (l *lookup) sortByDistance(nodes []node) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return l.closer(nodes[i].id, nodes[j].id)
+	})
+}
+
+// closer reports whether a is closer to the target than b by XOR.
+func (l *lookup) closer(a, b [20]byte) bool {
+	for i := range l.target {
+		da, db := a[i]^l.target[i], b[i]^l.target[i]
+		if da != db {
+			return da < db
+		}
+	}
+	return false
+}
+
+func (l *lookup) query(ctx context.Context, n node) {
+	timeout := l.client.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	r, err := l.s.query(ctx, n.addr, "get_peers", map[string]interface{}{
+		"id":        string(l.id[:]),
+		"info_hash": string(l.target[:]),
+	})
+	if err != nil {
+		return
+	}
+	id, _ := r["id"].(string)
+	if len(id) != 20 {
+		return
+	}
+	copy(n.id[:], id)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.answered[n.id] = n
+	if values, ok := r["values"].([]interface{}); ok {
+		for _, v := range values {
+			b, _ := v.(string)
+			if len(b) != 6 {
+				continue
+			}
+			ip, _ := netip.AddrFromSlice([]byte(b[:4]))
+			peer := netip.AddrPortFrom(ip, binary.BigEndian.Uint16([]byte(b[4:])))
+			if !l.seen[peer] {
+				l.seen[peer] = true
+				l.peers = append(l.peers, peer)
+			}
+		}
+	}
+	nodes, _ := r["nodes"].(string)
+	for b := []byte(nodes); len(b) >= compactNodeLen; b = b[compactNodeLen:] {
+		var c node
+		copy(c.id[:], b[:20])
+		ip, _ := netip.AddrFromSlice(b[20:24])
+		c.addr = netip.AddrPortFrom(ip, binary.BigEndian.Uint16(b[24:26]))
+		if c.addr.Port() == 0 || c.id == l.id {
+			continue
+		}
+		l.candidates = append(l.candidates, c)
+	}
+}
+
+// session is the UDP socket of one lookup, matching responses to
+// queries by transaction id.
+type session struct {
+	conn *net.UDPConn
+
+	mu      sync.Mutex
+	nextTx  uint16
+	pending map[string]chan map[string]interface{}
+}
+
+func (s *session) close() {
+	s.conn.Close()
+}
+
+func (s *session) read() {
+	buf := make([]byte, 4096)
+	for {
+		n, _, err := s.conn.ReadFromUDPAddrPort(buf)
+		if err != nil {
+			return
+		}
+		v, err := bencode.Decode(buf[:n])
+		if err != nil {
+			continue
+		}
+		msg, _ := v.(map[string]interface{})
+		tx, _ := msg["t"].(string)
+		s.mu.Lock()
+		ch, ok := s.pending[tx]
+		delete(s.pending, tx)
+		s.mu.Unlock()
+		if ok {
+			ch <- msg
+		}
+	}
+}
+
+// query sends a KRPC query and returns the "r" dictionary of the
+// response.
+func (s *session) query(ctx context.Context, addr netip.AddrPort, method string, args map[string]interface{}) (map[string]interface{}, error) {
+	ch := make(chan map[string]interface{}, 1)
+	s.mu.Lock()
+	s.nextTx++
+	tx := string(binary.BigEndian.AppendUint16(nil, s.nextTx))
+	s.pending[tx] = ch
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, tx)
+		s.mu.Unlock()
+	}()
+
+	msg, err := bencode.Encode(map[string]interface{}{"t": tx, "y": "q", "q": method, "a": args})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.conn.WriteToUDPAddrPort(msg, addr); err != nil {
+		return nil, err
+	}
+	select {
+	case resp := <-ch:
+		switch resp["y"] {
+		case "r":
+			r, ok := resp["r"].(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("dht: response without r")
+			}
+			return r, nil
+		case "e":
+			return nil, fmt.Errorf("dht: error response %v", resp["e"])
+		}
+		return nil, fmt.Errorf("dht: unexpected message type %v", resp["y"])
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,220 @@
+package dht
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/netip"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nmmh/magneturi/magneturi"
+	"github.com/nmmh/magneturi/magneturi/bencode"
+)
+
+// simNode is a node of the simulated DHT network. It knows a few
+// other nodes and, when it is one of the closest to an info-hash,
+// the peers of that torrent.
+type simNode struct {
+	id    [20]byte
+	conn  *net.UDPConn
+	known []simContact
+	peers map[[20]byte][]netip.AddrPort
+
+	mu      sync.Mutex
+	queries int
+}
+
+type simContact struct {
+	id   [20]byte
+	addr netip.AddrPort
+}
+
+// simNetwork starts n nodes on the loopback interface. Every node
+// knows its closest neighbors and a handful of far away nodes, like a
+// real routing table, and the three nodes closest to infoHash store
+// peers for it.
+func simNetwork(t *testing.T, n int, infoHash [20]byte, peers []netip.AddrPort) []*simNode {
+	nodes := make([]*simNode, n)
+	for i := range nodes {
+		conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		nodes[i] = &simNode{
+			id:    sha1.Sum([]byte("node " + strconv.Itoa(i))),
+			conn:  conn,
+			peers: map[[20]byte][]netip.AddrPort{},
+		}
+		t.Cleanup(func() { conn.Close() })
+	}
+	for i, nd := range nodes {
+		byDistance := append([]*simNode{}, nodes...)
+		sortByDistance(byDistance, nd.id)
+		for _, other := range byDistance[1:5] {
+			nd.known = append(nd.known, other.contact())
+		}
+		for j := 1; j <= 4; j++ {
+			nd.known = append(nd.known, nodes[(i+j*n/5)%n].contact())
+		}
+	}
+	byDistance := append([]*simNode{}, nodes...)
+	sortByDistance(byDistance, infoHash)
+	for _, nd := range byDistance[:3] {
+		nd.peers[infoHash] = peers
+	}
+	for _, nd := range nodes {
+		go nd.serve()
+	}
+	return nodes
+}
+
+func sortByDistance(nodes []*simNode, target [20]byte) {
+	l := &lookup{target: target}
+	sort.Slice(nodes, func(i, j int) bool { return l.closer(nodes[i].id, nodes[j].id) })
+}
+
+func (nd *simNode) contact() simContact {
+	return simContact{nd.id, nd.conn.LocalAddr().(*net.UDPAddr).AddrPort()}
+}
+
+func (nd *simNode) addr() string {
+	return nd.conn.LocalAddr().String()
+}
+
+func (nd *simNode) queryCount() int {
+	nd.mu.Lock()
+	defer nd.mu.Unlock()
+	return nd.queries
+}
+
+func (nd *simNode) serve() {
+	buf := make([]byte, 4096)
+	for {
+		n, from, err := nd.conn.ReadFromUDPAddrPort(buf)
+		if err != nil {
+			return
+		}
+		nd.mu.Lock()
+		nd.queries++
+		nd.mu.Unlock()
+		v, err := bencode.Decode(buf[:n])
+		if err != nil {
+			continue
+		}
+		msg := v.(map[string]interface{})
+		resp := map[string]interface{}{"t": msg["t"], "y": "r"}
+		a, _ := msg["a"].(map[string]interface{})
+		target, _ := a["info_hash"].(string)
+		if msg["q"] != "get_peers" || len(target) != 20 {
+			resp["y"] = "e"
+			resp["e"] = []interface{}{int64(204), "method unknown"}
+		} else {
+			var h [20]byte
+			copy(h[:], target)
+			r := map[string]interface{}{"id": string(nd.id[:]), "token": "tok"}
+			if peers, ok := nd.peers[h]; ok {
+				var values []interface{}
+				for _, p := range peers {
+					values = append(values, string(binary.BigEndian.AppendUint16(p.Addr().AsSlice(), p.Port())))
+				}
+				r["values"] = values
+			}
+			var nodes []byte
+			for _, c := range nd.closestKnown(h, 8) {
+				nodes = append(nodes, c.id[:]...)
+				nodes = append(nodes, c.addr.Addr().AsSlice()...)
+				nodes = binary.BigEndian.AppendUint16(nodes, c.addr.Port())
+			}
+			r["nodes"] = string(nodes)
+			resp["r"] = r
+		}
+		b, _ := bencode.Encode(resp)
+		nd.conn.WriteToUDPAddrPort(b, from)
+	}
+}
+
+func (nd *simNode) closestKnown(target [20]byte, k int) []simContact {
+	known := append([]simContact{}, nd.known...)
+	l := &lookup{target: target}
+	sort.Slice(known, func(i, j int) bool { return l.closer(known[i].id, known[j].id) })
+	if len(known) > k {
+		known = known[:k]
+	}
+	return known
+}
+
+func TestClient_GetPeers(t *testing.T) {
+	infoHash, _ := magneturi.ParseInfoHash("c12fe1c06bba254a9dc9f519b335aa7c1367a88a")
+	peers := []netip.AddrPort{
+		netip.MustParseAddrPort("10.0.0.1:6881"),
+		netip.MustParseAddrPort("10.0.0.2:51413"),
+	}
+	nodes := simNetwork(t, 60, infoHash, peers)
+	m := magneturi.MustParse("magnet:?xt=urn:btih:" + infoHash.String())
+
+	//start from the node farthest away from the info-hash
+	byDistance := append([]*simNode{}, nodes...)
+	sortByDistance(byDistance, infoHash)
+	far := byDistance[len(byDistance)-1]
+	c := &Client{Bootstrap: []string{far.addr()}, Timeout: 500 * time.Millisecond}
+	got, err := c.GetPeers(context.Background(), m)
+	if err != nil {
+		t.Fatalf("Client.GetPeers() error = %v", err)
+	}
+	if len(got) != 2 || got[0] != peers[0] || got[1] != peers[1] {
+		t.Errorf("Client.GetPeers() = %v, want %v", got, peers)
+	}
+	queried := 0
+	for _, nd := range nodes {
+		if nd.queryCount() > 0 {
+			queried++
+		}
+	}
+	if queried == len(nodes) {
+		t.Errorf("Client.GetPeers() queried every node instead of converging")
+	}
+}
+
+func TestClient_GetPeersMaxPeers(t *testing.T) {
+	infoHash, _ := magneturi.ParseInfoHash("c12fe1c06bba254a9dc9f519b335aa7c1367a88a")
+	peers := []netip.AddrPort{netip.MustParseAddrPort("10.0.0.1:6881")}
+	nodes := simNetwork(t, 20, infoHash, peers)
+	c := &Client{Bootstrap: []string{nodes[0].addr()}, Timeout: 500 * time.Millisecond, MaxPeers: 1}
+	got, err := c.GetPeersFor(context.Background(), infoHash)
+	if err != nil || len(got) != 1 {
+		t.Errorf("Client.GetPeersFor() = %v, %v, want %v", got, err, peers)
+	}
+}
+
+func TestClient_GetPeersNoAnswer(t *testing.T) {
+	//a socket that never answers
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	infoHash, _ := magneturi.ParseInfoHash("c12fe1c06bba254a9dc9f519b335aa7c1367a88a")
+	c := &Client{Bootstrap: []string{conn.LocalAddr().String()}, Timeout: 50 * time.Millisecond}
+	if _, err := c.GetPeersFor(context.Background(), infoHash); err == nil {
+		t.Errorf("Client.GetPeersFor() without answers, error = nil")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	c.Timeout = time.Second
+	if _, err := c.GetPeersFor(ctx, infoHash); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Client.GetPeersFor() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestClient_GetPeersWithoutBtih(t *testing.T) {
+	if _, err := (&Client{}).GetPeers(context.Background(), magneturi.MustParse("magnet:?dn=x")); err == nil {
+		t.Errorf("Client.GetPeers() without btih, error = nil")
+	}
+}
@@ -0,0 +1,49 @@
+package magneturi
+
+import "fmt"
+
+// ParseErrorKind tells why Parse rejected a URI.
+type ParseErrorKind int
+
+// The kinds of ParseError.
+const (
+	// KindScheme is a URI that does not start with "magnet:?".
+	KindScheme ParseErrorKind = iota + 1
+	// KindSyntax is a parameter that is not of the form prefix=value.
+	KindSyntax
+	// KindPrefix is a parameter with an unknown prefix.
+	KindPrefix
+	// KindIndex is a dotted prefix without its index, or an x.
+	// parameter without its name.
+	KindIndex
+)
+
+var parseErrorKindNames = map[ParseErrorKind]string{
+	KindScheme: "scheme",
+	KindSyntax: "syntax",
+	KindPrefix: "prefix",
+	KindIndex:  "index",
+}
+
+func (k ParseErrorKind) String() string {
+	if name, ok := parseErrorKindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("ParseErrorKind(%d)", int(k))
+}
+
+// ParseError is the error Parse returns, Input is the URI or the
+// parameter that was rejected.
+type ParseError struct {
+	Kind  ParseErrorKind
+	Input string
+	msg   string
+}
+
+func (e *ParseError) Error() string {
+	return e.msg
+}
+
+func parseErrorf(kind ParseErrorKind, input, format string, args ...interface{}) *ParseError {
+	return &ParseError{kind, input, fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,33 @@
+package magneturi
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		name      string
+		raw       string
+		wantKind  ParseErrorKind
+		wantInput string
+	}{
+		{"scheme", "http://example.org", KindScheme, "http://example.org"},
+		{"syntax", "magnet:?xt=", KindSyntax, "xt="},
+		{"prefix", "magnet:?zz=1", KindPrefix, "zz=1"},
+		{"dot index", "magnet:?xt.=1", KindIndex, "xt."},
+		{"experimental name", "magnet:?x.=1", KindIndex, "x."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.raw, false)
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("Parse() error = %v, want a *ParseError", err)
+			}
+			if perr.Kind != tt.wantKind || perr.Input != tt.wantInput {
+				t.Errorf("Parse() error kind %v input %q, want %v %q", perr.Kind, perr.Input, tt.wantKind, tt.wantInput)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package magneturi
+
+import (
+	"net/url"
+	"path"
+	"runtime"
+	"strings"
+	"unicode/utf8"
+)
+
+// windowsReserved are the device names Windows reserves, with or
+// without an extension.
+var windowsReserved = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"COM¹": true, "COM²": true, "COM³": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+	"LPT¹": true, "LPT²": true, "LPT³": true,
+	"CONIN$": true, "CONOUT$": true,
+}
+
+// maxFilename is the longest file name, 255 UTF-16 code units on
+// Windows (NTFS) and macOS (HFS+), 255 bytes elsewhere.
+const maxFilename = 255
+
+// maxExtension is the longest extension kept when a name is cut.
+const maxExtension = 16
+
+// SafeFilename returns a file name for the content of the link that
+// is safe to join to a download directory on goos, runtime.GOOS if
+// empty. It is the first display name (dn), or else the hash of the
+// preferred exact topic, or "magnet":
+//   - path separators and characters the system does not allow in
+//     names become "_", so the result is always a single path element
+//   - invalid UTF-8, control characters and bidirectional text
+//     controls are removed, leading dots and spaces too, which rules
+//     out "." and ".." and hidden files, and the name is NFC
+//     normalized
+//   - on Windows trailing dots and spaces are removed and reserved
+//     device names such as CON or LPT1.txt get a "_" prefix
+//   - names longer than the file system allows are cut, keeping the
+//     extension
+func (m *MagnetURI) SafeFilename(goos string) string {
+	if goos == "" {
+		goos = runtime.GOOS
+	}
+	if name, ok := m.firstValue("dn"); ok {
+		if name = safeFilename(decodeName(name), goos); name != "" {
+			return name
+		}
+	}
+	if t, ok := m.preferredTopic(); ok {
+		hash := t.Hash
+		if h, err := ParseInfoHash(hash); err == nil && t.Namespace == string(BTIH) {
+			hash = h.String()
+		}
+		if name := safeFilename(hash, goos); name != "" {
+			return name
+		}
+	}
+	return "magnet"
+}
+
+func decodeName(value string) string {
+	if decoded, err := url.QueryUnescape(value); err == nil {
+		return decoded
+	}
+	return value
+}
+
+func safeFilename(name, goos string) string {
+	name = nfc(cleanName(strings.ToValidUTF8(name, "")))
+	invalid := "/"
+	switch goos {
+	case "windows":
+		invalid = `/\<>:"|?*`
+	case "darwin", "ios":
+		invalid = "/:"
+	}
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(invalid, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.TrimLeft(name, ". ")
+	if goos == "windows" {
+		name = strings.TrimRight(name, ". ")
+		stem, _, _ := strings.Cut(name, ".")
+		if windowsReserved[strings.ToUpper(strings.TrimRight(stem, " "))] {
+			name = "_" + name
+		}
+	}
+	name = truncateFilename(name, goos)
+	if goos == "windows" {
+		name = strings.TrimRight(name, ". ")
+	}
+	return name
+}
+
+// truncateFilename cuts the name to the length limit of goos, from
+// the end of the stem so the extension survives.
+func truncateFilename(name, goos string) string {
+	width := utf8.RuneLen
+	if goos == "windows" || goos == "darwin" || goos == "ios" {
+		// code units, a surrogate pair above the Basic Multilingual Plane
+		width = func(r rune) int {
+			if r >= 0x10000 {
+				return 2
+			}
+			return 1
+		}
+	}
+	length := func(s string) int {
+		n := 0
+		for _, r := range s {
+			n += width(r)
+		}
+		return n
+	}
+	if length(name) <= maxFilename {
+		return name
+	}
+	ext := path.Ext(name)
+	if len(ext) > maxExtension {
+		ext = ""
+	}
+	stem := strings.TrimSuffix(name, ext)
+	n := length(ext)
+	for i, r := range stem {
+		if n+width(r) > maxFilename {
+			return stem[:i] + ext
+		}
+		n += width(r)
+	}
+	return stem + ext
+}
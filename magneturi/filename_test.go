@@ -0,0 +1,69 @@
+package magneturi
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestSafeFilename(t *testing.T) {
+	const hash = "magnet:?xt=urn:btih:YEX6DQDLXISUVHOJ6UM3GNNKPQJWPKEK"
+	tests := []struct {
+		name string
+		link string
+		goos string
+		want string
+	}{
+		{"plain", "magnet:?dn=Example+File.iso", "linux", "Example File.iso"},
+		{"traversal", "magnet:?dn=..%2F..%2Fetc%2Fpasswd", "linux", "_.._etc_passwd"},
+		{"dot dot", hash + "&dn=..", "linux", "c12fe1c06bba254a9dc9f519b335aa7c1367a88a"},
+		{"hidden", "magnet:?dn=.bashrc", "linux", "bashrc"},
+		{"backslash kept on linux", "magnet:?dn=a%5Cb", "linux", `a\b`},
+		{"windows separators", "magnet:?dn=..%5C..%5CWindows%5Cwin.ini", "windows", "_.._Windows_win.ini"},
+		{"windows invalid characters", "magnet:?dn=a%3Cb%3E%3A%22%7C%3F%2A.txt", "windows", "a_b______.txt"},
+		{"windows trailing dots", "magnet:?dn=file.txt.+.", "windows", "file.txt"},
+		{"windows reserved", "magnet:?dn=con", "windows", "_con"},
+		{"windows reserved with extension", "magnet:?dn=LPT1.tar.gz", "windows", "_LPT1.tar.gz"},
+		{"reserved only on windows", "magnet:?dn=con", "linux", "con"},
+		{"darwin colon", "magnet:?dn=a%3Ab", "darwin", "a_b"},
+		{"invalid utf-8", "magnet:?dn=a%FFb%C3", "linux", "ab"},
+		{"control and bidi", "magnet:?dn=photo%E2%80%AEgpj.exe%00%0A", "linux", "photogpj.exe"},
+		{"no name", hash, "linux", "c12fe1c06bba254a9dc9f519b335aa7c1367a88a"},
+		{"nothing", "magnet:?tr=udp://a:1", "linux", "magnet"},
+		{"empty after cleaning", hash + "&dn=%2E%2E%00", "linux", "c12fe1c06bba254a9dc9f519b335aa7c1367a88a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MustParse(tt.link).SafeFilename(tt.goos); got != tt.want {
+				t.Errorf("SafeFilename(%q) = %q, want %q", tt.goos, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSafeFilenameLength(t *testing.T) {
+	tests := []struct {
+		name    string
+		dn      string
+		goos    string
+		wantLen int
+		wantExt string
+	}{
+		{"bytes", strings.Repeat("%C3%A9", 200) + ".mkv", "linux", 255, ".mkv"},
+		{"utf-16", strings.Repeat("%C3%A9", 300) + ".mkv", "windows", 255, ".mkv"},
+		{"surrogate pairs", strings.Repeat("%F0%9F%8E%B5", 200) + ".mp3", "darwin", 255, ".mp3"},
+		{"long extension dropped", strings.Repeat("a", 300) + "." + strings.Repeat("b", 20), "linux", 255, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MustParse("magnet:?dn=" + tt.dn).SafeFilename(tt.goos)
+			n := len(got)
+			if tt.goos != "linux" {
+				n = len(utf16.Encode([]rune(got)))
+			}
+			if n > tt.wantLen || n < tt.wantLen-1 || !strings.HasSuffix(got, tt.wantExt) {
+				t.Errorf("SafeFilename(%q) has length %d: %q", tt.goos, n, got)
+			}
+		})
+	}
+}
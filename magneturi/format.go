@@ -0,0 +1,97 @@
+package magneturi
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// MustParse is like Parse without soft parsing but panics if the raw
+// URI cannot be parsed. It simplifies initialization of variables
+// holding well known links and is what %#v prints.
+func MustParse(rawMagnetURI string) *MagnetURI {
+	m, err := Parse(rawMagnetURI, false)
+	if err != nil {
+		panic("magneturi: Parse(" + strconv.Quote(rawMagnetURI) + "): " + err.Error())
+	}
+	return m
+}
+
+// Format implements fmt.Formatter.
+//
+//	%v, %s  the URI, as String returns it
+//	%+v     a decoded summary with the name, size and hash
+//	%#v     a Go expression building the same MagnetURI
+//	%q      the quoted URI
+func (m *MagnetURI) Format(f fmt.State, verb rune) {
+	if m == nil {
+		fmt.Fprint(f, "<nil>")
+		return
+	}
+	switch {
+	case verb == 'v' && f.Flag('+'):
+		fmt.Fprint(f, m.summary())
+	case verb == 'v' && f.Flag('#'):
+		fmt.Fprintf(f, "magneturi.MustParse(%q)", m.String())
+	case verb == 'v' || verb == 's' || verb == 'q':
+		fmt.Fprintf(f, fmt.FormatString(f, verb), m.String())
+	default:
+		fmt.Fprintf(f, "%%!%c(*magneturi.MagnetURI=%s)", verb, m.String())
+	}
+}
+
+// summary is the name, size and preferred hash of the link, falling
+// back to Describe when none of them are present.
+func (m *MagnetURI) summary() string {
+	var parts []string
+	if dn, ok := m.firstValue("dn"); ok {
+		if decoded, err := url.QueryUnescape(dn); err == nil {
+			dn = decoded
+		}
+		parts = append(parts, "name="+strconv.Quote(dn))
+	}
+	if xl, ok := m.firstValue("xl"); ok {
+		if n, err := strconv.ParseInt(xl, 10, 64); err == nil {
+			parts = append(parts, "size="+formatSize(n))
+		} else {
+			parts = append(parts, "size="+strconv.Quote(xl))
+		}
+	}
+	if t, ok := m.preferredTopic(); ok {
+		parts = append(parts, "hash="+t.Namespace+":"+t.Hash)
+	}
+	if len(parts) == 0 {
+		return m.Describe()
+	}
+	return strings.Join(parts, " ")
+}
+
+// preferredTopic returns the BitTorrent topic if there is one,
+// otherwise the first exact topic.
+func (m *MagnetURI) preferredTopic() (ExactTopic, bool) {
+	topics := m.ExactTopics()
+	if len(topics) == 0 {
+		return ExactTopic{}, false
+	}
+	for _, t := range topics {
+		if t.Namespace == "btih" || t.Namespace == "btmh" {
+			return t, true
+		}
+	}
+	return topics[0], true
+}
+
+// formatSize writes n bytes in binary units, e.g. 10.3MiB.
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return strconv.FormatInt(n, 10) + "B"
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return strconv.FormatFloat(float64(n)/float64(div), 'f', 1, 64) + string("KMGTPE"[exp]) + "iB"
+}
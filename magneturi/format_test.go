@@ -0,0 +1,71 @@
+package magneturi
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMagnetURI_Format(t *testing.T) {
+	m := &MagnetURI{
+		params: []param{
+			param{"xt", "1", "urn:ed2k:354B15E68FB8F36D7CD88FF94116CDC1"},
+			param{"xt", "2", "urn:btih:QHQXPYWMACKDWKP47RRVIV7VOURXFE5Q"},
+			param{"xl", "", "10826029"},
+			param{"dn", "", "mediawiki%201.15.1.tar.gz"},
+		},
+	}
+	uri := "magnet:?xt.1=urn:ed2k:354B15E68FB8F36D7CD88FF94116CDC1&xt.2=urn:btih:QHQXPYWMACKDWKP47RRVIV7VOURXFE5Q&xl=10826029&dn=mediawiki%201.15.1.tar.gz"
+	tests := []struct {
+		name   string
+		format string
+		m      *MagnetURI
+		want   string
+	}{
+		{"v", "%v", m, uri},
+		{"s", "%s", m, uri},
+		{"q", "%q", m, `"` + uri + `"`},
+		{"plus v", "%+v", m, `name="mediawiki 1.15.1.tar.gz" size=10.3MiB hash=btih:QHQXPYWMACKDWKP47RRVIV7VOURXFE5Q`},
+		{"sharp v", "%#v", m, `magneturi.MustParse("` + uri + `")`},
+		{"plus v kt only", "%+v", &MagnetURI{params: []param{param{"kt", "", "a+b"}}}, "Magnet URI with 1 parameters: 1 keywordTopic"},
+		{"unknown verb", "%d", &MagnetURI{}, "%!d(*magneturi.MagnetURI=magnet:?)"},
+		{"nil", "%v", nil, "<nil>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fmt.Sprintf(tt.format, tt.m); got != tt.want {
+				t.Errorf("fmt.Sprintf(%q) = %v, want %v", tt.format, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMustParse(t *testing.T) {
+	raw := "magnet:?xt=urn:btih:QHQXPYWMACKDWKP47RRVIV7VOURXFE5Q&dn=test"
+	if got := MustParse(fmt.Sprintf("%v", MustParse(raw))).String(); got != raw {
+		t.Errorf("MustParse() = %v, want %v", got, raw)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("MustParse() did not panic on an invalid URI")
+		}
+	}()
+	MustParse("mUgnet")
+}
+
+func Test_formatSize(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0B"},
+		{1023, "1023B"},
+		{1024, "1.0KiB"},
+		{10826029, "10.3MiB"},
+		{5 << 30, "5.0GiB"},
+	}
+	for _, tt := range tests {
+		if got := formatSize(tt.n); got != tt.want {
+			t.Errorf("formatSize(%d) = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+//go:build ignore
+
+// gen_nfc writes nfc_tables.go, the data behind NFC normalization,
+// from UnicodeData.txt and CompositionExclusions.txt of the Unicode
+// Character Database:
+//
+//	go run gen_nfc.go -ucd path/to/ucd -version 14.0.0
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	ucd := flag.String("ucd", ".", "the directory holding the UCD files")
+	version := flag.String("version", "", "the Unicode version of the UCD files")
+	out := flag.String("o", "nfc_tables.go", "the file to write")
+	flag.Parse()
+	if *version == "" {
+		log.Fatal("-version is required")
+	}
+
+	classes := map[rune]int{}
+	decompositions := map[rune][]rune{}
+	readFields(filepath.Join(*ucd, "UnicodeData.txt"), func(fields []string) {
+		r := parseRune(fields[0])
+		if ccc, _ := strconv.Atoi(fields[3]); ccc != 0 {
+			classes[r] = ccc
+		}
+		// Compatibility decompositions start with a <tag>.
+		if d := fields[5]; d != "" && !strings.HasPrefix(d, "<") {
+			for _, s := range strings.Fields(d) {
+				decompositions[r] = append(decompositions[r], parseRune(s))
+			}
+		}
+	})
+	var exclusions []rune
+	readFields(filepath.Join(*ucd, "CompositionExclusions.txt"), func(fields []string) {
+		exclusions = append(exclusions, parseRune(fields[0]))
+	})
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by gen_nfc.go from the Unicode Character Database %s. DO NOT EDIT.\n\n", *version)
+	fmt.Fprintf(&b, "package magneturi\n\n")
+	fmt.Fprintf(&b, "// nfcUnicodeVersion is the Unicode version of the tables.\n")
+	fmt.Fprintf(&b, "const nfcUnicodeVersion = %q\n\n", *version)
+
+	fmt.Fprintf(&b, "// nfcClasses are the ranges of characters with a canonical combining\n// class other than 0.\n")
+	fmt.Fprintf(&b, "var nfcClasses = []nfcClassRange{\n")
+	runes := sortedKeys(classes)
+	for i := 0; i < len(runes); {
+		j := i
+		for j+1 < len(runes) && runes[j+1] == runes[j]+1 && classes[runes[j+1]] == classes[runes[i]] {
+			j++
+		}
+		fmt.Fprintf(&b, "{0x%04X, 0x%04X, %d},\n", runes[i], runes[j], classes[runes[i]])
+		i = j + 1
+	}
+	fmt.Fprintf(&b, "}\n\n")
+
+	fmt.Fprintf(&b, "// nfcDecompositions are the canonical decompositions one level deep,\n// singletons have a zero second rune.\n")
+	fmt.Fprintf(&b, "var nfcDecompositions = map[rune][2]rune{\n")
+	for i, r := range sortedKeys(decompositions) {
+		d := decompositions[r]
+		if len(d) > 2 {
+			log.Fatalf("U+%04X decomposes into %d runes", r, len(d))
+		}
+		second := rune(0)
+		if len(d) == 2 {
+			second = d[1]
+		}
+		fmt.Fprintf(&b, "0x%04X: {0x%04X, 0x%04X},", r, d[0], second)
+		if i%4 == 3 {
+			b.WriteString("\n")
+		} else {
+			b.WriteString(" ")
+		}
+	}
+	fmt.Fprintf(&b, "\n}\n\n")
+
+	sort.Slice(exclusions, func(i, j int) bool { return exclusions[i] < exclusions[j] })
+	fmt.Fprintf(&b, "// nfcExclusions are the characters excluded from composition by\n// CompositionExclusions.txt.\n")
+	fmt.Fprintf(&b, "var nfcExclusions = []rune{\n")
+	for i, r := range exclusions {
+		fmt.Fprintf(&b, "0x%04X,", r)
+		if i%8 == 7 {
+			b.WriteString("\n")
+		} else {
+			b.WriteString(" ")
+		}
+	}
+	fmt.Fprintf(&b, "\n}\n")
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// readFields calls fn with the semicolon separated fields of every
+// line of a UCD file that is not blank or a comment.
+func readFields(name string, fn func([]string)) {
+	f, err := os.Open(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Split(line, ";")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		fn(fields)
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func parseRune(s string) rune {
+	n, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		log.Fatalf("invalid code point %q", s)
+	}
+	return rune(n)
+}
+
+func sortedKeys[V any](m map[rune]V) []rune {
+	keys := make([]rune, 0, len(m))
+	for r := range m {
+		keys = append(keys, r)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
@@ -0,0 +1,370 @@
+package magneturi
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/nmmh/magneturi/magneturi/bencode"
+)
+
+// Algorithm is a content hash FromReader can compute. The values are
+// the urn namespaces the hashes are published under in exact topics.
+type Algorithm string
+
+// The supported algorithms.
+const (
+	// ED2K is the eDonkey2000 hash, MD4 over 9.28 MB chunks.
+	ED2K Algorithm = "ed2k"
+	// TTH is the Tiger Tree Hash root over 1024 byte leaves.
+	TTH Algorithm = "tree:tiger"
+	// SHA1 is the plain SHA-1 of the content as used by Gnutella.
+	SHA1 Algorithm = "sha1"
+	// BTIH is the BitTorrent v1 info-hash of a single file torrent.
+	BTIH Algorithm = "btih"
+	// BTMH is the BitTorrent v2 info-hash of a single file torrent,
+	// as a SHA2-256 multihash.
+	BTMH Algorithm = "btmh"
+)
+
+// Algorithms are all supported algorithms, the default of FromReader.
+var Algorithms = []Algorithm{ED2K, TTH, SHA1, BTIH, BTMH}
+
+const (
+	ed2kChunkSize = 9728000
+	tthLeafSize   = 1024
+	btBlockSize   = 16 * 1024
+)
+
+var base32NoPad = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// FromFile hashes the file at path with the given algorithms, or all
+// supported ones if none are given, and returns a MagnetURI with an
+// exact topic per algorithm, the exact length and the file name.
+func FromFile(path string, algos ...Algorithm) (*MagnetURI, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !fi.Mode().IsRegular() {
+		return nil, fmt.Errorf("not a regular file: %q", path)
+	}
+	return FromReader(f, filepath.Base(path), fi.Size(), algos...)
+}
+
+// FromReader is FromFile for content read from r, which is read once.
+// size is the expected content length or -1 if it is not known, it
+// decides the BitTorrent piece length and FromReader fails if r does
+// not deliver exactly size bytes.
+func FromReader(r io.Reader, name string, size int64, algos ...Algorithm) (*MagnetURI, error) {
+	if len(algos) == 0 {
+		algos = Algorithms
+	}
+	hashes := make([]contentHash, 0, len(algos))
+	writers := make([]io.Writer, 0, len(algos))
+	for _, algo := range algos {
+		h, err := newContentHash(algo, name, size)
+		if err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, h)
+		writers = append(writers, h)
+	}
+	n, err := io.Copy(io.MultiWriter(writers...), r)
+	if err != nil {
+		return nil, err
+	}
+	if size >= 0 && n != size {
+		return nil, fmt.Errorf("read %d bytes, expected %d", n, size)
+	}
+	m := &MagnetURI{}
+	for i, h := range hashes {
+		index := ""
+		if len(hashes) > 1 {
+			index = strconv.Itoa(i + 1)
+		}
+		m.params = append(m.params, param{"xt", index, ExactTopic{index, string(algos[i]), h.topicHash(n)}.String()})
+	}
+	m.params = append(m.params, param{"xl", "", strconv.FormatInt(n, 10)})
+	if name != "" {
+		m.params = append(m.params, param{"dn", "", url.QueryEscape(name)})
+	}
+	return m, nil
+}
+
+// contentHash is a streaming hash whose result is written the way it
+// appears in an exact topic.
+type contentHash interface {
+	io.Writer
+	topicHash(n int64) string
+}
+
+func newContentHash(algo Algorithm, name string, size int64) (contentHash, error) {
+	switch algo {
+	case ED2K:
+		return &ed2kHash{chunk: newMD4()}, nil
+	case TTH:
+		return &tthHash{}, nil
+	case SHA1:
+		return &sha1Hash{sha1.New()}, nil
+	case BTIH:
+		return &btv1Hash{name: name, pieceLen: btPieceLength(size), piece: sha1.New()}, nil
+	case BTMH:
+		return &btv2Hash{name: name, pieceLen: btPieceLength(size)}, nil
+	}
+	return nil, fmt.Errorf("unsupported hash algorithm: %q", algo)
+}
+
+// btPieceLength picks a power of two between 16 KiB and 16 MiB that
+// keeps the piece count around 1500, or 256 KiB for an unknown size.
+func btPieceLength(size int64) int64 {
+	if size < 0 {
+		return 256 * 1024
+	}
+	pl := int64(btBlockSize)
+	for size/pl > 1500 && pl < 16*1024*1024 {
+		pl *= 2
+	}
+	return pl
+}
+
+// chunked feeds p to fn in slices that do not cross a boundary of
+// size bytes, given that n bytes were already written.
+func chunked(p []byte, n, size int64, fn func(p []byte, end bool)) {
+	for len(p) > 0 {
+		room := size - n%size
+		if int64(len(p)) < room {
+			fn(p, false)
+			return
+		}
+		fn(p[:room], true)
+		n += room
+		p = p[room:]
+	}
+}
+
+type sha1Hash struct {
+	hash.Hash
+}
+
+func (h *sha1Hash) topicHash(int64) string {
+	return base32NoPad.EncodeToString(h.Sum(nil))
+}
+
+// ed2kHash follows the original eDonkey convention: content of a
+// chunk or more is hashed as the MD4 of the chunk hashes, including
+// an empty last chunk for content that is an exact multiple.
+type ed2kHash struct {
+	chunk  hash.Hash
+	n      int64
+	chunks []byte
+}
+
+func (h *ed2kHash) Write(p []byte) (int, error) {
+	chunked(p, h.n, ed2kChunkSize, func(p []byte, end bool) {
+		h.chunk.Write(p)
+		if end {
+			h.chunks = h.chunk.Sum(h.chunks)
+			h.chunk.Reset()
+		}
+	})
+	h.n += int64(len(p))
+	return len(p), nil
+}
+
+func (h *ed2kHash) topicHash(int64) string {
+	if len(h.chunks) == 0 {
+		return fmt.Sprintf("%X", h.chunk.Sum(nil))
+	}
+	root := newMD4()
+	root.Write(h.chunk.Sum(h.chunks))
+	return fmt.Sprintf("%X", root.Sum(nil))
+}
+
+// tthHash builds the THEX tree bottom up, keeping one pending node
+// per level.
+type tthHash struct {
+	leaf  bytes.Buffer
+	n     int64
+	stack []tthNode
+}
+
+type tthNode struct {
+	level int
+	hash  []byte
+}
+
+func (h *tthHash) Write(p []byte) (int, error) {
+	chunked(p, h.n, tthLeafSize, func(p []byte, end bool) {
+		h.leaf.Write(p)
+		if end {
+			h.pushLeaf()
+		}
+	})
+	h.n += int64(len(p))
+	return len(p), nil
+}
+
+func (h *tthHash) pushLeaf() {
+	node := tthNode{0, tthDigest(0x00, h.leaf.Bytes())}
+	h.leaf.Reset()
+	for len(h.stack) > 0 && h.stack[len(h.stack)-1].level == node.level {
+		left := h.stack[len(h.stack)-1]
+		h.stack = h.stack[:len(h.stack)-1]
+		node = tthNode{node.level + 1, tthDigest(0x01, left.hash, node.hash)}
+	}
+	h.stack = append(h.stack, node)
+}
+
+func (h *tthHash) topicHash(int64) string {
+	if h.leaf.Len() > 0 || h.n == 0 {
+		h.pushLeaf()
+	}
+	//unpaired nodes are promoted, so folding from the right is enough
+	root := h.stack[len(h.stack)-1].hash
+	for i := len(h.stack) - 2; i >= 0; i-- {
+		root = tthDigest(0x01, h.stack[i].hash, root)
+	}
+	return base32NoPad.EncodeToString(root)
+}
+
+func tthDigest(prefix byte, parts ...[]byte) []byte {
+	d := newTiger()
+	d.Write([]byte{prefix})
+	for _, p := range parts {
+		d.Write(p)
+	}
+	return d.Sum(nil)
+}
+
+type btv1Hash struct {
+	name     string
+	pieceLen int64
+	piece    hash.Hash
+	n        int64
+	pieces   []byte
+}
+
+func (h *btv1Hash) Write(p []byte) (int, error) {
+	chunked(p, h.n, h.pieceLen, func(p []byte, end bool) {
+		h.piece.Write(p)
+		if end {
+			h.pieces = h.piece.Sum(h.pieces)
+			h.piece.Reset()
+		}
+	})
+	h.n += int64(len(p))
+	return len(p), nil
+}
+
+func (h *btv1Hash) topicHash(n int64) string {
+	pieces := h.pieces
+	if n%h.pieceLen != 0 {
+		pieces = h.piece.Sum(pieces)
+	}
+	info := sha1.Sum(bencodeInfoV1(h.name, n, h.pieceLen, pieces))
+	return hex.EncodeToString(info[:])
+}
+
+// btv2Hash collects the SHA-256 of every 16 KiB block, the leaves of
+// the file's merkle tree.
+type btv2Hash struct {
+	name     string
+	pieceLen int64
+	block    bytes.Buffer
+	n        int64
+	leaves   [][]byte
+}
+
+func (h *btv2Hash) Write(p []byte) (int, error) {
+	chunked(p, h.n, btBlockSize, func(p []byte, end bool) {
+		h.block.Write(p)
+		if end {
+			sum := sha256.Sum256(h.block.Bytes())
+			h.leaves = append(h.leaves, sum[:])
+			h.block.Reset()
+		}
+	})
+	h.n += int64(len(p))
+	return len(p), nil
+}
+
+func (h *btv2Hash) topicHash(n int64) string {
+	leaves := h.leaves
+	if h.block.Len() > 0 {
+		sum := sha256.Sum256(h.block.Bytes())
+		leaves = append(leaves, sum[:])
+	}
+	info := sha256.Sum256(bencodeInfoV2(h.name, n, h.pieceLen, merkleRoot(leaves)))
+	//multihash: sha2-256 code 0x12, digest length 0x20
+	return "1220" + hex.EncodeToString(info[:])
+}
+
+// merkleRoot pads the leaves with zero hashes to a power of two and
+// hashes them pairwise up to the root.
+func merkleRoot(leaves [][]byte) []byte {
+	if len(leaves) == 0 {
+		return nil
+	}
+	width := 1
+	for width < len(leaves) {
+		width *= 2
+	}
+	layer := make([][]byte, width)
+	copy(layer, leaves)
+	for i := len(leaves); i < width; i++ {
+		layer[i] = make([]byte, sha256.Size)
+	}
+	for len(layer) > 1 {
+		next := make([][]byte, len(layer)/2)
+		for i := range next {
+			sum := sha256.Sum256(append(append([]byte{}, layer[2*i]...), layer[2*i+1]...))
+			next[i] = sum[:]
+		}
+		layer = next
+	}
+	return layer[0]
+}
+
+// bencodeInfoV1 is the info dictionary of a single file v1 torrent.
+func bencodeInfoV1(name string, length, pieceLen int64, pieces []byte) []byte {
+	info, _ := bencode.Encode(map[string]interface{}{
+		"length":       length,
+		"name":         name,
+		"piece length": pieceLen,
+		"pieces":       pieces,
+	})
+	return info
+}
+
+// bencodeInfoV2 is the info dictionary of a single file v2 torrent,
+// empty files have no pieces root.
+func bencodeInfoV2(name string, length, pieceLen int64, root []byte) []byte {
+	file := map[string]interface{}{"length": length}
+	if length > 0 {
+		file["pieces root"] = root
+	}
+	info, _ := bencode.Encode(map[string]interface{}{
+		"file tree": map[string]interface{}{
+			name: map[string]interface{}{"": file},
+		},
+		"meta version": 2,
+		"name":         name,
+		"piece length": pieceLen,
+	})
+	return info
+}
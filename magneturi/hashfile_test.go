@@ -0,0 +1,115 @@
+package magneturi
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFromReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "empty",
+			content: "",
+			want: "magnet:?xt.1=urn:ed2k:31D6CFE0D16AE931B73C59D7E0C089C0" +
+				"&xt.2=urn:tree:tiger:LWPNACQDBZRYXW3VHJVCJ64QBZNGHOHHHZWCLNQ" +
+				"&xt.3=urn:sha1:3I42H3S6NNFQ2MSVX7XZKYAYSCX5QBYJ" +
+				"&xt.4=urn:btih:3a6d2ccd44c6f87637ba3be3bd48b8dcf84fdba8" +
+				"&xt.5=urn:btmh:1220e9634a1fe4892f75c203bf537267478fdcc46ef8e28e43f4e8f19fd763d002d9" +
+				"&xl=0&dn=test.bin",
+		},
+		{
+			name:    "one tiger leaf",
+			content: strings.Repeat("A", 1024),
+			want: "magnet:?xt.1=urn:ed2k:EE9E857A30FE7D5A0DC88CF9FC68B2C7" +
+				"&xt.2=urn:tree:tiger:L66Q4YVNAFWVS23X2HJIRA5ZJ7WXR3F26RSASFA" +
+				"&xt.3=urn:sha1:ORWD6TJINRJR4BS6RL3W4CWAQ2EDDRVU" +
+				"&xt.4=urn:btih:ee48d356f895201121d69c42d5d70a5d912796fa" +
+				"&xt.5=urn:btmh:1220365f9256366c947b55274ef9992e1642f8913d1bd20dafcfc6cacd346ae53515" +
+				"&xl=1024&dn=test.bin",
+		},
+		{
+			name:    "two tiger leaves",
+			content: strings.Repeat("A", 1025),
+			want: "magnet:?xt.1=urn:ed2k:809D2CCA45678F9E8F4ABE56D266FA39" +
+				"&xt.2=urn:tree:tiger:PZMRYHGY6LTBEH63ZWAHDORHSYTLO4LEFUIKHWY" +
+				"&xt.3=urn:sha1:UUHHSQPHQXN5X6EMYK6CD7IJ7BHZTE77" +
+				"&xt.4=urn:btih:376604d37327c99eda552f7336d4cedf7cfe0960" +
+				"&xt.5=urn:btmh:1220ee8fe700a01609ddd00dfd5db0b92590944757b1ba46fd34f6c1e5b4d8714c97" +
+				"&xl=1025&dn=test.bin",
+		},
+		{
+			name:    "several pieces and blocks",
+			content: strings.Repeat("x", 100000),
+			want: "magnet:?xt.1=urn:ed2k:D7639C0042D8077EC0464E5D4605BD6C" +
+				"&xt.2=urn:tree:tiger:4GLKH2DSMKHHY6HVCYRZKGNBGY34KRVJELLY2KA" +
+				"&xt.3=urn:sha1:63XJT3O6MGM2H2MCYRXPOK65LS26IHO7" +
+				"&xt.4=urn:btih:75ad2170c321a3647f6ff67655791099affdacd0" +
+				"&xt.5=urn:btmh:1220d8fabec4ab61f91c491889044e56590edfffbb220257f7b77186f5b9ccaf7049" +
+				"&xl=100000&dn=test.bin",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromReader(strings.NewReader(tt.content), "test.bin", int64(len(tt.content)))
+			if err != nil {
+				t.Fatalf("FromReader() error = %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("FromReader() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromReader_ed2kChunks(t *testing.T) {
+	content := bytes.Repeat([]byte{7}, ed2kChunkSize+1)
+	first, rest := newMD4(), newMD4()
+	first.Write(content[:ed2kChunkSize])
+	rest.Write(content[ed2kChunkSize:])
+	root := newMD4()
+	root.Write(rest.Sum(first.Sum(nil)))
+	want := fmt.Sprintf("magnet:?xt=urn:ed2k:%X&xl=%d", root.Sum(nil), len(content))
+
+	got, err := FromReader(bytes.NewReader(content), "", -1, ED2K)
+	if err != nil {
+		t.Fatalf("FromReader() error = %v", err)
+	}
+	if got.String() != want {
+		t.Errorf("FromReader() = %v, want %v", got, want)
+	}
+}
+
+func TestFromReader_errors(t *testing.T) {
+	if _, err := FromReader(strings.NewReader("abc"), "a", 4); err == nil {
+		t.Errorf("FromReader() with a short read, error = nil")
+	}
+	if _, err := FromReader(strings.NewReader("abc"), "a", 3, "md5"); err == nil {
+		t.Errorf("FromReader() with an unsupported algorithm, error = nil")
+	}
+}
+
+func TestFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "media wiki.txt")
+	if err := os.WriteFile(path, []byte("abc"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := FromFile(path, SHA1, ED2K)
+	if err != nil {
+		t.Fatalf("FromFile() error = %v", err)
+	}
+	want := "magnet:?xt.1=urn:sha1:VGMT4NSHA2AWVOR6EVYXQUGCNSONBWE5&xt.2=urn:ed2k:A448017AAF21D8525FC10AE87AA6729D&xl=3&dn=media+wiki.txt"
+	if got.String() != want {
+		t.Errorf("FromFile() = %v, want %v", got, want)
+	}
+	if _, err := FromFile(filepath.Dir(path)); err == nil {
+		t.Errorf("FromFile() on a directory, error = nil")
+	}
+}
@@ -0,0 +1,343 @@
+package magneturi
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Host is a host name in the two forms of an internationalized domain
+// name (IDN): ASCII, with non-ASCII labels in punycode (RFC 3492), for
+// comparison and lookups, and Unicode for display. IP addresses and
+// ASCII names are the same in both.
+type Host struct {
+	ASCII   string
+	Unicode string
+}
+
+const acePrefix = "xn--"
+
+// labelSeparators are the full stops IDNA treats like ".".
+var labelSeparators = strings.NewReplacer("。", ".", "．", ".", "｡", ".")
+
+// ParseHost converts a host name given in either form. Labels are
+// lower cased and NFC normalized, punycode labels must decode to
+// valid Unicode that encodes back to the same label.
+func ParseHost(host string) (Host, error) {
+	if !utf8.ValidString(host) {
+		return Host{}, fmt.Errorf("host is not valid UTF-8: %q", host)
+	}
+	if ip := net.ParseIP(strings.Trim(host, "[]")); ip != nil {
+		return Host{host, host}, nil
+	}
+	labels := strings.Split(labelSeparators.Replace(host), ".")
+	ascii := make([]string, len(labels))
+	display := make([]string, len(labels))
+	for i, label := range labels {
+		if label == "" && (i < len(labels)-1 || i == 0) {
+			return Host{}, fmt.Errorf("host with an empty label: %q", host)
+		}
+		label = nfc(strings.ToLower(label))
+		var err error
+		if strings.HasPrefix(label, acePrefix) {
+			ascii[i] = label
+			display[i], err = punycodeDecode(label[len(acePrefix):])
+			if err == nil && isASCII(display[i]) {
+				err = errors.New("punycode of an ASCII label")
+			}
+			if err == nil && nfc(strings.ToLower(display[i])) != display[i] {
+				err = errors.New("punycode of a label that is not lower case NFC")
+			}
+			if err == nil {
+				if again, _ := punycodeEncode(display[i]); acePrefix+again != label {
+					err = errors.New("punycode is not in canonical form")
+				}
+			}
+		} else if isASCII(label) {
+			ascii[i], display[i] = label, label
+		} else {
+			display[i] = label
+			ascii[i], err = punycodeEncode(label)
+			ascii[i] = acePrefix + ascii[i]
+		}
+		if err != nil {
+			return Host{}, fmt.Errorf("invalid label %q of host %q: %v", label, host, err)
+		}
+		if len(ascii[i]) > 63 {
+			return Host{}, fmt.Errorf("host with a label longer than 63 bytes: %q", host)
+		}
+	}
+	h := Host{strings.Join(ascii, "."), strings.Join(display, ".")}
+	if len(strings.TrimSuffix(h.ASCII, ".")) > 253 {
+		return Host{}, fmt.Errorf("host longer than 253 bytes: %q", host)
+	}
+	return h, nil
+}
+
+func (h Host) String() string {
+	return h.Unicode
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// Punycode parameters of RFC 3492.
+const (
+	punyBase        = 36
+	punyTMin        = 1
+	punyTMax        = 26
+	punySkew        = 38
+	punyDamp        = 700
+	punyInitialBias = 72
+	punyInitialN    = 128
+)
+
+var errPunycodeOverflow = errors.New("punycode overflow")
+
+func punyAdapt(delta, points int, first bool) int {
+	if first {
+		delta /= punyDamp
+	} else {
+		delta /= 2
+	}
+	delta += delta / points
+	k := 0
+	for delta > (punyBase-punyTMin)*punyTMax/2 {
+		delta /= punyBase - punyTMin
+		k += punyBase
+	}
+	return k + (punyBase-punyTMin+1)*delta/(delta+punySkew)
+}
+
+func punyDigit(d int) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+	return byte('0' + d - 26)
+}
+
+func punyThreshold(k, bias int) int {
+	switch {
+	case k <= bias:
+		return punyTMin
+	case k >= bias+punyTMax:
+		return punyTMax
+	}
+	return k - bias
+}
+
+// punycodeEncode encodes a label without the xn-- prefix.
+func punycodeEncode(label string) (string, error) {
+	runes := []rune(label)
+	var out []byte
+	for _, r := range runes {
+		if r < utf8.RuneSelf {
+			out = append(out, byte(r))
+		}
+	}
+	basic := len(out)
+	if basic > 0 {
+		out = append(out, '-')
+	}
+	n, delta, bias := punyInitialN, 0, punyInitialBias
+	for handled := basic; handled < len(runes); {
+		m := math.MaxInt32
+		for _, r := range runes {
+			if int(r) >= n && int(r) < m {
+				m = int(r)
+			}
+		}
+		if (m - n) > (math.MaxInt32-delta)/(handled+1) {
+			return "", errPunycodeOverflow
+		}
+		delta += (m - n) * (handled + 1)
+		n = m
+		for _, r := range runes {
+			if int(r) < n {
+				delta++
+			}
+			if int(r) != n {
+				continue
+			}
+			q := delta
+			for k := punyBase; ; k += punyBase {
+				t := punyThreshold(k, bias)
+				if q < t {
+					break
+				}
+				out = append(out, punyDigit(t+(q-t)%(punyBase-t)))
+				q = (q - t) / (punyBase - t)
+			}
+			out = append(out, punyDigit(q))
+			bias = punyAdapt(delta, handled+1, handled == basic)
+			delta = 0
+			handled++
+		}
+		delta++
+		n++
+	}
+	return string(out), nil
+}
+
+// punycodeDecode decodes a label without the xn-- prefix.
+func punycodeDecode(s string) (string, error) {
+	var out []rune
+	rest := s
+	if i := strings.LastIndexByte(s, '-'); i >= 0 {
+		for _, c := range s[:i] {
+			if c >= utf8.RuneSelf {
+				return "", errors.New("non-ASCII punycode")
+			}
+			out = append(out, c)
+		}
+		rest = s[i+1:]
+	}
+	n, i, bias := punyInitialN, 0, punyInitialBias
+	for pos := 0; pos < len(rest); {
+		oldi, w := i, 1
+		for k := punyBase; ; k += punyBase {
+			if pos == len(rest) {
+				return "", errors.New("truncated punycode")
+			}
+			c := rest[pos]
+			pos++
+			var digit int
+			switch {
+			case 'a' <= c && c <= 'z':
+				digit = int(c - 'a')
+			case 'A' <= c && c <= 'Z':
+				digit = int(c - 'A')
+			case '0' <= c && c <= '9':
+				digit = int(c-'0') + 26
+			default:
+				return "", fmt.Errorf("invalid punycode digit %q", c)
+			}
+			if digit > (math.MaxInt32-i)/w {
+				return "", errPunycodeOverflow
+			}
+			i += digit * w
+			t := punyThreshold(k, bias)
+			if digit < t {
+				break
+			}
+			if w > math.MaxInt32/(punyBase-t) {
+				return "", errPunycodeOverflow
+			}
+			w *= punyBase - t
+		}
+		bias = punyAdapt(i-oldi, len(out)+1, oldi == 0)
+		if i/(len(out)+1) > math.MaxInt32-n {
+			return "", errPunycodeOverflow
+		}
+		n += i / (len(out) + 1)
+		i %= len(out) + 1
+		if n > unicode.MaxRune || (n >= 0xD800 && n <= 0xDFFF) {
+			return "", errors.New("punycode decodes to an invalid code point")
+		}
+		out = append(out, 0)
+		copy(out[i+1:], out[i:])
+		out[i] = rune(n)
+		i++
+	}
+	return string(out), nil
+}
+
+// allowedMixes are the script combinations of UTS #39's Highly
+// Restrictive level, the ways Latin is written together with East
+// Asian scripts.
+var allowedMixes = []map[string]bool{
+	{"Latin": true, "Han": true, "Hiragana": true, "Katakana": true},
+	{"Latin": true, "Han": true, "Bopomofo": true},
+	{"Latin": true, "Han": true, "Hangul": true},
+}
+
+// latinLookalikes are the Cyrillic and Greek letters that render like
+// Latin ones, a label made only of them passes for a Latin one.
+// Host labels are lower case.
+const latinLookalikes = "асԁеһіјӏорԛѕԝхуѵ" + "αικνορτυχ"
+
+// IsHomograph reports whether a label of the host could pass for a
+// different name: it mixes scripts other than as allowed for Chinese,
+// Japanese and Korean, or it is written entirely in Cyrillic or Greek
+// letters that look like Latin ones, like "аррӏе".
+func (h Host) IsHomograph() bool {
+	for _, label := range strings.Split(h.Unicode, ".") {
+		if isASCII(label) {
+			continue
+		}
+		scripts := map[string]bool{}
+		lookalikes := true
+		for _, r := range label {
+			if !unicode.IsLetter(r) {
+				continue
+			}
+			if script := scriptOf(r); script != "" {
+				scripts[script] = true
+			}
+			lookalikes = lookalikes && strings.ContainsRune(latinLookalikes, r)
+		}
+		if len(scripts) == 1 && (scripts["Cyrillic"] || scripts["Greek"]) && lookalikes {
+			return true
+		}
+		if len(scripts) > 1 && !allowedMix(scripts) {
+			return true
+		}
+	}
+	return false
+}
+
+// scriptOf returns the Unicode script of r, "" for Common and
+// Inherited.
+func scriptOf(r rune) string {
+	for name, table := range unicode.Scripts {
+		if name != "Common" && name != "Inherited" && unicode.Is(table, r) {
+			return name
+		}
+	}
+	return ""
+}
+
+func allowedMix(scripts map[string]bool) bool {
+	for _, allowed := range allowedMixes {
+		ok := true
+		for s := range scripts {
+			ok = ok && allowed[s]
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// HomographHosts returns the distinct hosts of the trackers and
+// sources of the link that are homographs as defined by
+// Host.IsHomograph.
+func (m *MagnetURI) HomographHosts() []Host {
+	var hosts []Host
+	seen := map[string]bool{}
+	add := func(host string) {
+		h, err := ParseHost(host)
+		if err == nil && !seen[h.ASCII] && h.IsHomograph() {
+			seen[h.ASCII] = true
+			hosts = append(hosts, h)
+		}
+	}
+	for _, t := range m.Trackers() {
+		add(t.Host)
+	}
+	for _, s := range m.Sources() {
+		add(s.Host.ASCII)
+	}
+	return hosts
+}
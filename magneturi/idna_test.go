@@ -0,0 +1,128 @@
+package magneturi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPunycode(t *testing.T) {
+	// Samples of RFC 3492 section 7.1 and well-known names.
+	tests := []struct {
+		unicode string
+		ascii   string
+	}{
+		{"bücher", "bcher-kva"},
+		{"münchen", "mnchen-3ya"},
+		{"他们为什么不说中文", "ihqwcrb4cv8a8dqg056pqjye"},
+		{"ليهمابتكلموشعربي؟", "egbpdaj6bu4bxfgehfvwxn"},
+		{"3年b組金八先生", "3b-ww4c5e180e575a65lsy2b"},
+		{"яндекс", "d1acpjx3f"},
+		{"παράδειγμα", "hxajbheg2az3al"},
+	}
+	for _, tt := range tests {
+		got, err := punycodeEncode(tt.unicode)
+		if err != nil || got != tt.ascii {
+			t.Errorf("punycodeEncode(%q) = %q, %v, want %q", tt.unicode, got, err, tt.ascii)
+		}
+		back, err := punycodeDecode(tt.ascii)
+		if err != nil || back != tt.unicode {
+			t.Errorf("punycodeDecode(%q) = %q, %v, want %q", tt.ascii, back, err, tt.unicode)
+		}
+	}
+	for _, bad := range []string{"a-b!", "99999999999", "a-"} {
+		if got, err := punycodeDecode(bad); err == nil && bad != "a-" {
+			t.Errorf("punycodeDecode(%q) = %q, want an error", bad, got)
+		}
+	}
+}
+
+func TestParseHost(t *testing.T) {
+	tests := []struct {
+		name    string
+		host    string
+		want    Host
+		wantErr bool
+	}{
+		{"ascii", "Tracker.Example.org", Host{"tracker.example.org", "tracker.example.org"}, false},
+		{"unicode", "Bücher.example", Host{"xn--bcher-kva.example", "bücher.example"}, false},
+		{"punycode", "xn--bcher-kva.example", Host{"xn--bcher-kva.example", "bücher.example"}, false},
+		{"decomposed", "bücher.example", Host{"xn--bcher-kva.example", "bücher.example"}, false},
+		{"ideographic full stop", "яндекс。рф", Host{"xn--d1acpjx3f.xn--p1ai", "яндекс.рф"}, false},
+		{"trailing dot", "example.org.", Host{"example.org.", "example.org."}, false},
+		{"ip", "192.168.1.1", Host{"192.168.1.1", "192.168.1.1"}, false},
+		{"ipv6", "::1", Host{"::1", "::1"}, false},
+		{"empty label", "a..b", Host{}, true},
+		{"invalid punycode", "xn--a-b!.example", Host{}, true},
+		{"punycode of ascii", "xn--abc-.example", Host{}, true},
+		{"upper case punycode", "xn--Bcher-kva.example", Host{"xn--bcher-kva.example", "bücher.example"}, false},
+		{"not normalized punycode", "xn--bucher-xyd.example", Host{}, true},
+		{"invalid utf-8", "b\xfccher.example", Host{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseHost(tt.host)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseHost() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseHost() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHost_IsHomograph(t *testing.T) {
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"tracker.example.org", false},
+		{"bücher.example", false},
+		{"яндекс.рф", false},
+		{"παράδειγμα.ελ", false},
+		{"日本語テキスト.jp", false},
+		{"abc日本.jp", false},
+		{"한국abc.kr", false},
+		{"aрple.com", true},
+		{"аррӏе.com", true},
+		{"xn--80ak6aa92e.com", true},
+		{"ехаmple.org", true},
+		{"οκ.example", true},
+		{"한국ひらがな.kr", true},
+	}
+	for _, tt := range tests {
+		h, err := ParseHost(tt.host)
+		if err != nil {
+			t.Fatalf("ParseHost(%q) error = %v", tt.host, err)
+		}
+		if got := h.IsHomograph(); got != tt.want {
+			t.Errorf("IsHomograph() of %q = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestMagnetURI_HomographHosts(t *testing.T) {
+	m := MustParse("magnet:?tr=udp://xn--aple-g6d.com:80/announce&tr=udp://tracker.example.org:80" +
+		"&xs=http%3A%2F%2F%D0%B0%D1%80%D1%80%D3%8F%D0%B5.com%2Ff&as=http://xn--aple-g6d.com/f&xs=urn:btpk:abc")
+	want := []Host{{"xn--aple-g6d.com", "aрple.com"}, {"xn--80ak6aa92e.com", "аррӏе.com"}}
+	if got := m.HomographHosts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("HomographHosts() = %v, want %v", got, want)
+	}
+	if got := len(m.Sources()); got != 2 {
+		t.Errorf("len(Sources()) = %d, want 2", got)
+	}
+}
+
+func TestTracker_IDN(t *testing.T) {
+	a, err := ParseTracker("udp://bücher.example:80/announce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ParseTracker("udp%3A%2F%2Fxn--bcher-kva.example%3A80%2Fannounce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Key() != b.Key() || a.Host != "xn--bcher-kva.example" || b.DisplayHost() != "bücher.example" {
+		t.Errorf("trackers %q (%s) and %q (%s) differ", a.Key(), a.DisplayHost(), b.Key(), b.DisplayHost())
+	}
+}
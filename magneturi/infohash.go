@@ -0,0 +1,57 @@
+package magneturi
+
+import (
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// InfoHash is a BitTorrent v1 info-hash, the SHA-1 of a torrent's
+// info dictionary.
+type InfoHash [20]byte
+
+// ParseInfoHash parses the 40 character hex or 32 character base32
+// form of an info-hash.
+func ParseInfoHash(s string) (InfoHash, error) {
+	var h InfoHash
+	var b []byte
+	var err error
+	switch len(s) {
+	case 40:
+		b, err = hex.DecodeString(s)
+	case 32:
+		b, err = base32.StdEncoding.DecodeString(strings.ToUpper(s))
+	default:
+		return h, fmt.Errorf("invalid info-hash length: %q", s)
+	}
+	if err != nil {
+		return h, fmt.Errorf("invalid info-hash: %q", s)
+	}
+	copy(h[:], b)
+	return h, nil
+}
+
+// String returns the lower case hex form of the info-hash.
+func (h InfoHash) String() string {
+	return hex.EncodeToString(h[:])
+}
+
+// InfoHashes returns the distinct info-hashes of the btih exact
+// topics in link order, malformed ones are skipped.
+func (m *MagnetURI) InfoHashes() []InfoHash {
+	var hashes []InfoHash
+	seen := map[InfoHash]bool{}
+	for _, t := range m.ExactTopics() {
+		if t.Namespace != string(BTIH) {
+			continue
+		}
+		h, err := ParseInfoHash(t.Hash)
+		if err != nil || seen[h] {
+			continue
+		}
+		seen[h] = true
+		hashes = append(hashes, h)
+	}
+	return hashes
+}
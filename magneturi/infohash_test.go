@@ -0,0 +1,45 @@
+package magneturi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseInfoHash(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    string
+		wantErr bool
+	}{
+		{"hex", "C12FE1C06BBA254A9DC9F519B335AA7C1367A88A", "c12fe1c06bba254a9dc9f519b335aa7c1367a88a", false},
+		{"base32", "QHQXPYWMACKDWKP47RRVIV7VOURXFE5Q", "81e177e2cc00943b29fcfc635457f575237293b0", false},
+		{"lower base32", "qhqxpywmackdwkp47rrviv7vourxfe5q", "81e177e2cc00943b29fcfc635457f575237293b0", false},
+		{"bad hex", "Z12FE1C06BBA254A9DC9F519B335AA7C1367A88A", "", true},
+		{"bad length", "C12FE1C0", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseInfoHash(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseInfoHash() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got.String() != tt.want {
+				t.Errorf("ParseInfoHash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMagnetURI_InfoHashes(t *testing.T) {
+	m := MustParse("magnet:?xt.1=urn:btih:QHQXPYWMACKDWKP47RRVIV7VOURXFE5Q&xt.2=urn:ed2k:354B15E68FB8F36D7CD88FF94116CDC1" +
+		"&xt.3=urn:btih:81e177e2cc00943b29fcfc635457f575237293b0&xt.4=urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a")
+	var got []string
+	for _, h := range m.InfoHashes() {
+		got = append(got, h.String())
+	}
+	want := []string{"81e177e2cc00943b29fcfc635457f575237293b0", "c12fe1c06bba254a9dc9f519b335aa7c1367a88a"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MagnetURI.InfoHashes() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,330 @@
+// Package trackertest runs an in-process BitTorrent tracker speaking
+// the HTTP and BEP 15 UDP protocols on the loopback interface, so the
+// tracker clients can be tested offline.
+package trackertest
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"sync"
+
+	"github.com/nmmh/magneturi/magneturi/bencode"
+	"github.com/nmmh/magneturi/magneturi/internal/udptracker"
+)
+
+// Swarm is what the tracker knows about one torrent.
+type Swarm struct {
+	Seeders   int
+	Leechers  int
+	Completed int
+	Peers     []Peer
+}
+
+// Peer is a peer the tracker hands out on announce.
+type Peer struct {
+	ID   string
+	Addr netip.AddrPort
+}
+
+// Announce is an announce request the tracker received.
+type Announce struct {
+	InfoHash [20]byte
+	PeerID   [20]byte
+	Port     int
+	Left     int64
+	Event    string
+	NumWant  int
+}
+
+// AnnounceInterval is the re-announce interval the tracker asks for.
+const AnnounceInterval = 1800
+
+// Tracker is a fake tracker listening on UDP and HTTP.
+type Tracker struct {
+	mu     sync.Mutex
+	swarms map[[20]byte]Swarm
+	conns  map[uint64]bool
+	drop   int
+	fail   string
+
+	announces []Announce
+
+	udp  net.PacketConn
+	http *httptest.Server
+	wg   sync.WaitGroup
+}
+
+// New starts a tracker, Close stops it.
+func New() *Tracker {
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		panic("trackertest: " + err.Error())
+	}
+	t := &Tracker{
+		swarms: map[[20]byte]Swarm{},
+		conns:  map[uint64]bool{},
+		udp:    udp,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/scrape", t.serveScrape)
+	mux.HandleFunc("/announce", t.serveAnnounce)
+	t.http = httptest.NewServer(mux)
+	t.wg.Add(1)
+	go t.serveUDP()
+	return t
+}
+
+// Close stops the tracker.
+func (t *Tracker) Close() {
+	t.udp.Close()
+	t.http.Close()
+	t.wg.Wait()
+}
+
+// UDPAnnounceURL is the announce URL of the UDP tracker.
+func (t *Tracker) UDPAnnounceURL() string {
+	return "udp://" + t.udp.LocalAddr().String() + "/announce"
+}
+
+// HTTPAnnounceURL is the announce URL of the HTTP tracker.
+func (t *Tracker) HTTPAnnounceURL() string {
+	return t.http.URL + "/announce"
+}
+
+// SetSwarm sets the statistics reported for an info-hash.
+func (t *Tracker) SetSwarm(infoHash [20]byte, s Swarm) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.swarms[infoHash] = s
+}
+
+// DropUDP makes the tracker ignore the next n UDP packets, to test
+// retransmission.
+func (t *Tracker) DropUDP(n int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.drop = n
+}
+
+// Fail makes every following request fail with reason, an empty
+// reason makes the tracker work again.
+func (t *Tracker) Fail(reason string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.fail = reason
+}
+
+// Announces returns the announce requests received so far.
+func (t *Tracker) Announces() []Announce {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Announce{}, t.announces...)
+}
+
+func (t *Tracker) failure() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.fail
+}
+
+func (t *Tracker) announce(a Announce) Swarm {
+	t.mu.Lock()
+	t.announces = append(t.announces, a)
+	t.mu.Unlock()
+	return t.swarm(a.InfoHash)
+}
+
+func (t *Tracker) swarm(infoHash [20]byte) Swarm {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.swarms[infoHash]
+}
+
+func (t *Tracker) serveUDP() {
+	defer t.wg.Done()
+	buf := make([]byte, 2048)
+	for {
+		n, addr, err := t.udp.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		t.mu.Lock()
+		drop := t.drop > 0
+		if drop {
+			t.drop--
+		}
+		t.mu.Unlock()
+		if drop || n < 16 {
+			continue
+		}
+		if resp := t.handleUDP(buf[:n]); resp != nil {
+			t.udp.WriteTo(resp, addr)
+		}
+	}
+}
+
+func (t *Tracker) handleUDP(req []byte) []byte {
+	connID := binary.BigEndian.Uint64(req[0:8])
+	action := binary.BigEndian.Uint32(req[8:12])
+	txID := req[12:16]
+	if reason := t.failure(); reason != "" {
+		return udpError(txID, reason)
+	}
+	resp := binary.BigEndian.AppendUint32(nil, action)
+	resp = append(resp, txID...)
+	if action == udptracker.ActionConnect {
+		if connID != udptracker.ProtocolID {
+			return udpError(txID, "bad protocol id")
+		}
+		var id [8]byte
+		rand.Read(id[:])
+		t.mu.Lock()
+		t.conns[binary.BigEndian.Uint64(id[:])] = true
+		t.mu.Unlock()
+		return append(resp, id[:]...)
+	}
+	t.mu.Lock()
+	known := t.conns[connID]
+	t.mu.Unlock()
+	if !known {
+		return udpError(txID, "unknown connection id")
+	}
+	switch action {
+	case udptracker.ActionScrape:
+		for body := req[16:]; len(body) >= 20; body = body[20:] {
+			var h [20]byte
+			copy(h[:], body)
+			s := t.swarm(h)
+			resp = binary.BigEndian.AppendUint32(resp, uint32(s.Seeders))
+			resp = binary.BigEndian.AppendUint32(resp, uint32(s.Completed))
+			resp = binary.BigEndian.AppendUint32(resp, uint32(s.Leechers))
+		}
+		return resp
+	case udptracker.ActionAnnounce:
+		if len(req) < 98 {
+			return udpError(txID, "short announce")
+		}
+		a := Announce{
+			Left:    int64(binary.BigEndian.Uint64(req[64:72])),
+			Event:   udpEvents[binary.BigEndian.Uint32(req[80:84])],
+			NumWant: int(int32(binary.BigEndian.Uint32(req[92:96]))),
+			Port:    int(binary.BigEndian.Uint16(req[96:98])),
+		}
+		copy(a.InfoHash[:], req[16:36])
+		copy(a.PeerID[:], req[36:56])
+		s := t.announce(a)
+		resp = binary.BigEndian.AppendUint32(resp, AnnounceInterval)
+		resp = binary.BigEndian.AppendUint32(resp, uint32(s.Leechers))
+		resp = binary.BigEndian.AppendUint32(resp, uint32(s.Seeders))
+		for _, p := range s.Peers {
+			if p.Addr.Addr().Is4() {
+				resp = append(resp, p.Addr.Addr().AsSlice()...)
+				resp = binary.BigEndian.AppendUint16(resp, p.Addr.Port())
+			}
+		}
+		return resp
+	}
+	return udpError(txID, "unsupported action "+strconv.Itoa(int(action)))
+}
+
+func udpError(txID []byte, msg string) []byte {
+	resp := binary.BigEndian.AppendUint32(nil, udptracker.ActionError)
+	return append(append(resp, txID...), msg...)
+}
+
+var udpEvents = map[uint32]string{0: "", 1: "completed", 2: "started", 3: "stopped"}
+
+func (t *Tracker) serveScrape(w http.ResponseWriter, r *http.Request) {
+	if reason := t.failure(); reason != "" {
+		writeBencode(w, map[string]interface{}{"failure reason": reason})
+		return
+	}
+	hashes := r.URL.Query()["info_hash"]
+	if len(hashes) == 0 {
+		writeBencode(w, map[string]interface{}{"failure reason": "full scrape disabled"})
+		return
+	}
+	files := map[string]interface{}{}
+	for _, h := range hashes {
+		if len(h) != 20 {
+			writeBencode(w, map[string]interface{}{"failure reason": "invalid info_hash"})
+			return
+		}
+		var key [20]byte
+		copy(key[:], h)
+		s := t.swarm(key)
+		files[h] = map[string]interface{}{
+			"complete":   s.Seeders,
+			"incomplete": s.Leechers,
+			"downloaded": s.Completed,
+		}
+	}
+	writeBencode(w, map[string]interface{}{"files": files})
+}
+
+func writeBencode(w http.ResponseWriter, v interface{}) {
+	b, err := bencode.Encode(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write(b)
+}
+
+// serveAnnounce answers in the compact format unless the client sent
+// compact=0, IPv6 peers go to peers6 in the compact format.
+func (t *Tracker) serveAnnounce(w http.ResponseWriter, r *http.Request) {
+	if reason := t.failure(); reason != "" {
+		writeBencode(w, map[string]interface{}{"failure reason": reason})
+		return
+	}
+	q := r.URL.Query()
+	if len(q.Get("info_hash")) != 20 || len(q.Get("peer_id")) != 20 {
+		writeBencode(w, map[string]interface{}{"failure reason": "invalid info_hash or peer_id"})
+		return
+	}
+	a := Announce{Event: q.Get("event")}
+	copy(a.InfoHash[:], q.Get("info_hash"))
+	copy(a.PeerID[:], q.Get("peer_id"))
+	a.Port, _ = strconv.Atoi(q.Get("port"))
+	a.Left, _ = strconv.ParseInt(q.Get("left"), 10, 64)
+	a.NumWant, _ = strconv.Atoi(q.Get("numwant"))
+	s := t.announce(a)
+	resp := map[string]interface{}{
+		"interval":   AnnounceInterval,
+		"complete":   s.Seeders,
+		"incomplete": s.Leechers,
+	}
+	if q.Get("compact") == "0" {
+		var peers []interface{}
+		for _, p := range s.Peers {
+			peers = append(peers, map[string]interface{}{
+				"peer id": p.ID,
+				"ip":      p.Addr.Addr().String(),
+				"port":    int(p.Addr.Port()),
+			})
+		}
+		resp["peers"] = peers
+	} else {
+		var peers, peers6 []byte
+		for _, p := range s.Peers {
+			b := binary.BigEndian.AppendUint16(p.Addr.Addr().AsSlice(), p.Addr.Port())
+			if p.Addr.Addr().Is4() {
+				peers = append(peers, b...)
+			} else {
+				peers6 = append(peers6, b...)
+			}
+		}
+		resp["peers"] = peers
+		if len(peers6) > 0 {
+			resp["peers6"] = peers6
+		}
+	}
+	writeBencode(w, resp)
+}
@@ -0,0 +1,154 @@
+// Package udptracker implements the request/response exchange of the
+// BEP 15 UDP tracker protocol shared by the scrape and announce
+// clients.
+package udptracker
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+// ProtocolID is the magic constant a connect request starts with.
+const ProtocolID = 0x41727101980
+
+// The BEP 15 actions.
+const (
+	ActionConnect  = 0
+	ActionAnnounce = 1
+	ActionScrape   = 2
+	ActionError    = 3
+)
+
+// DefaultTimeout is how long the first attempt waits for an answer.
+const DefaultTimeout = 2 * time.Second
+
+// ErrTimeout is returned when the tracker did not answer in time.
+var ErrTimeout = errors.New("udp tracker did not answer")
+
+// Conn is a connection to a UDP tracker that has completed the
+// connect exchange.
+type Conn struct {
+	conn    net.Conn
+	connID  uint64
+	timeout time.Duration
+	retries int
+}
+
+// Dial connects to the tracker at host:port. Every request is sent up
+// to retries+1 times, the n-th attempt waiting timeout<<n for the
+// answer, but never past the deadline of ctx.
+func Dial(ctx context.Context, host string, port, retries int, timeout time.Duration) (*Conn, error) {
+	if port == 0 {
+		return nil, fmt.Errorf("udp tracker without port: %s", host)
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return nil, err
+	}
+	c := &Conn{conn: conn, connID: ProtocolID, timeout: timeout, retries: retries}
+	resp, err := c.Do(ctx, ActionConnect, nil, 16)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	c.connID = binary.BigEndian.Uint64(resp[8:16])
+	return c, nil
+}
+
+// Close closes the connection.
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// IPv6 reports whether the tracker is reached over IPv6, which makes
+// announce responses carry 18 byte peer entries.
+func (c *Conn) IPv6() bool {
+	addr, ok := c.conn.RemoteAddr().(*net.UDPAddr)
+	return ok && addr.IP.To4() == nil
+}
+
+// Do sends a request with the given action and body and returns the
+// whole response, which has to be at least minLen bytes long.
+func (c *Conn) Do(ctx context.Context, action uint32, body []byte, minLen int) ([]byte, error) {
+	var resp []byte
+	err := Retry(ctx, c.retries, func(attempt int) error {
+		var err error
+		resp, err = c.roundTrip(ctx, attempt, action, body, minLen)
+		return err
+	})
+	return resp, err
+}
+
+func (c *Conn) roundTrip(ctx context.Context, attempt int, action uint32, body []byte, minLen int) ([]byte, error) {
+	var txID [4]byte
+	rand.Read(txID[:])
+	req := binary.BigEndian.AppendUint64(nil, c.connID)
+	req = binary.BigEndian.AppendUint32(req, action)
+	req = append(append(req, txID[:]...), body...)
+	if _, err := c.conn.Write(req); err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(c.timeout << uint(attempt))
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	c.conn.SetReadDeadline(deadline)
+	buf := make([]byte, 4096)
+	for {
+		n, err := c.conn.Read(buf)
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				return nil, ErrTimeout
+			}
+			return nil, err
+		}
+		resp := buf[:n]
+		if n < 8 || string(resp[4:8]) != string(txID[:]) {
+			//a late answer to an earlier attempt
+			continue
+		}
+		switch got := binary.BigEndian.Uint32(resp[0:4]); got {
+		case ActionError:
+			return nil, fmt.Errorf("tracker error: %s", resp[8:])
+		case action:
+			if n < minLen {
+				return nil, fmt.Errorf("short udp response of %d bytes", n)
+			}
+			return append([]byte{}, resp...), nil
+		default:
+			return nil, fmt.Errorf("unexpected udp action %d", got)
+		}
+	}
+}
+
+// Retry calls fn until it succeeds, it was called retries+1 times or
+// ctx is done. Only timeouts and network errors are retried.
+func Retry(ctx context.Context, retries int, fn func(attempt int) error) error {
+	var err error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if err = fn(attempt); err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var ne net.Error
+		if err != ErrTimeout && !errors.As(err, &ne) {
+			return err
+		}
+	}
+	return err
+}
@@ -0,0 +1,138 @@
+package magneturi
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Keyword is a search term of a keyword topic (kt): a single token, or
+// a phrase given in double quotes whose words must appear together.
+type Keyword struct {
+	Text   string
+	Phrase bool
+}
+
+func (k Keyword) String() string {
+	if k.Phrase {
+		return `"` + k.Text + `"`
+	}
+	return k.Text
+}
+
+// Keywords are the terms of a search query, all of which must match.
+type Keywords []Keyword
+
+// String returns the keywords as a query, phrases in double quotes.
+func (ks Keywords) String() string {
+	terms := make([]string, len(ks))
+	for i, k := range ks {
+		terms[i] = k.String()
+	}
+	return strings.Join(terms, " ")
+}
+
+// ParseKeywords decodes a percent-encoded keyword topic (kt) value,
+// "martin+luther+king+mp3", into its keywords. Text in double quotes,
+// "%22martin+luther%22+mp3", is a phrase, an unterminated quote runs
+// to the end of the value. The value must be valid UTF-8 and the
+// keywords are NFC normalized.
+func ParseKeywords(value string) (Keywords, error) {
+	text, err := url.QueryUnescape(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid keyword topic encoding: %q", value)
+	}
+	if !utf8.ValidString(text) {
+		return nil, fmt.Errorf("keyword topic is not valid UTF-8: %q", value)
+	}
+	var keywords Keywords
+	quoted := false
+	for _, part := range strings.Split(nfc(text), `"`) {
+		if quoted {
+			if phrase := strings.Join(strings.Fields(part), " "); phrase != "" {
+				keywords = append(keywords, Keyword{phrase, true})
+			}
+		} else {
+			for _, token := range strings.Fields(part) {
+				keywords = append(keywords, Keyword{token, false})
+			}
+		}
+		quoted = !quoted
+	}
+	return keywords, nil
+}
+
+// KeywordTopic returns the keywords of every keyword topic (kt) of the
+// link in link order, as decoded by ParseKeywords, or nil if it has
+// none.
+func (m *MagnetURI) KeywordTopic() (Keywords, error) {
+	var keywords Keywords
+	for _, p := range m.params {
+		if p.prefix != "kt" {
+			continue
+		}
+		ks, err := ParseKeywords(p.value)
+		if err != nil {
+			return nil, err
+		}
+		keywords = append(keywords, ks...)
+	}
+	return keywords, nil
+}
+
+// Match reports whether every keyword occurs in one of the names, a
+// display name or the paths of a file list. Names and keywords are
+// compared as words, runs of letters and digits, ignoring case: "mp3"
+// matches "speech.mp3" but not "mp3s", and the words of a phrase, or
+// of a token such as "dr.who", must appear in order next to each
+// other. Keywords without letters or digits match anything.
+func (ks Keywords) Match(names ...string) bool {
+	words := make([][]string, len(names))
+	for i, name := range names {
+		words[i] = keywordWords(strings.ToValidUTF8(name, ""))
+	}
+	for _, k := range ks {
+		want := keywordWords(k.Text)
+		if len(want) == 0 {
+			continue
+		}
+		found := false
+		for _, w := range words {
+			if containsWords(w, want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// keywordWords splits s into lower case NFC words of letters and
+// digits.
+func keywordWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(nfc(s)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && !unicode.Is(unicode.Mn, r)
+	})
+}
+
+// containsWords reports whether want occurs in words as a run.
+func containsWords(words, want []string) bool {
+	for i := 0; i+len(want) <= len(words); i++ {
+		match := true
+		for j := range want {
+			if words[i+j] != want[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
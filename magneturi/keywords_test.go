@@ -0,0 +1,83 @@
+package magneturi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseKeywords(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    Keywords
+		wantErr bool
+	}{
+		{"tokens", "martin+luther+king+mp3", Keywords{{"martin", false}, {"luther", false}, {"king", false}, {"mp3", false}}, false},
+		{"phrase", "%22martin+luther+king%22+mp3", Keywords{{"martin luther king", true}, {"mp3", false}}, false},
+		{"literal quotes", `speech+"i++have+a+dream"`, Keywords{{"speech", false}, {"i have a dream", true}}, false},
+		{"unterminated phrase", "mp3+%22i+have", Keywords{{"mp3", false}, {"i have", true}}, false},
+		{"empty phrase", "%22%22+a", Keywords{{"a", false}}, false},
+		{"decomposed", "Cafe%CC%81", Keywords{{"Café", false}}, false},
+		{"empty", "", nil, false},
+		{"invalid utf-8", "caf%E9", nil, true},
+		{"invalid encoding", "100%", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseKeywords(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseKeywords() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseKeywords() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMagnetURI_KeywordTopic(t *testing.T) {
+	m := MustParse("magnet:?kt=%22martin+luther+king%22&dn=x&kt.1=mp3")
+	got, err := m.KeywordTopic()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `"martin luther king" mp3`; got.String() != want {
+		t.Errorf("KeywordTopic() = %s, want %s", got, want)
+	}
+	if got, err := MustParse("magnet:?dn=x").KeywordTopic(); got != nil || err != nil {
+		t.Errorf("KeywordTopic() without kt = %v, %v", got, err)
+	}
+}
+
+func TestKeywords_Match(t *testing.T) {
+	tests := []struct {
+		name  string
+		kt    string
+		names []string
+		want  bool
+	}{
+		{"all tokens", "martin+luther+king+mp3", []string{"Martin Luther King - Speech.mp3"}, true},
+		{"missing token", "martin+luther+king+flac", []string{"Martin Luther King - Speech.mp3"}, false},
+		{"whole words", "mp3", []string{"mp3s.txt"}, false},
+		{"across file list", "king+flac", []string{"Speeches/King.txt", "Audio/track01.flac"}, true},
+		{"phrase in order", "%22luther+king%22", []string{"martin_luther_king.mp3"}, true},
+		{"phrase out of order", "%22king+luther%22", []string{"martin luther king.mp3"}, false},
+		{"phrase across names", "%22luther+king%22", []string{"luther", "king"}, false},
+		{"dotted token", "dr.who", []string{"Dr Who S01E01.mkv"}, true},
+		{"case and normalization", "CAFE%CC%81", []string{"Café Society"}, true},
+		{"punctuation only", "-+mp3", []string{"a.mp3"}, true},
+		{"no names", "mp3", nil, false},
+		{"no keywords", "", []string{"a"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks, err := ParseKeywords(tt.kt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ks.Match(tt.names...); got != tt.want {
+				t.Errorf("Match(%q) of %s = %v, want %v", tt.names, ks, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package magneturi
+
+import (
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const redacted = "REDACTED"
+
+// LogOptions configure what the slog representation of a MagnetURI
+// reveals. The zero value logs tracker hosts only and redacts the
+// values of experimental (x.) parameters, which may hold secrets.
+type LogOptions struct {
+	// TrackerURLs logs the full tracker URLs instead of their hosts.
+	// Query values, /<passkey>/announce path segments and fragments,
+	// where private trackers keep the passkey, are redacted unless
+	// RevealTrackerQuery is set.
+	TrackerURLs        bool
+	RevealTrackerQuery bool
+	// RevealExperimental logs the values of x. parameters.
+	RevealExperimental bool
+}
+
+// LogValue implements slog.LogValuer with the zero LogOptions.
+func (m *MagnetURI) LogValue() slog.Value {
+	return m.logValue(LogOptions{})
+}
+
+// Loggable returns a slog.LogValuer for the MagnetURI that applies opts,
+// use it where the defaults of LogValue do not fit:
+//
+//	logger.Info("added", "magnet", m.Loggable(magneturi.LogOptions{TrackerURLs: true}))
+func (m *MagnetURI) Loggable(opts LogOptions) slog.LogValuer {
+	return logValuer{m, opts}
+}
+
+type logValuer struct {
+	m    *MagnetURI
+	opts LogOptions
+}
+
+func (l logValuer) LogValue() slog.Value {
+	return l.m.logValue(l.opts)
+}
+
+func (m *MagnetURI) logValue(opts LogOptions) slog.Value {
+	if m == nil {
+		return slog.StringValue("<nil>")
+	}
+	var attrs []slog.Attr
+	if t, ok := m.preferredTopic(); ok {
+		attrs = append(attrs, slog.String("hash", t.Namespace+":"+t.Hash))
+	}
+	if dn, ok := m.firstValue("dn"); ok {
+		if decoded, err := url.QueryUnescape(dn); err == nil {
+			dn = decoded
+		}
+		attrs = append(attrs, slog.String("name", dn))
+	}
+	if xl, ok := m.firstValue("xl"); ok {
+		if n, err := strconv.ParseInt(xl, 10, 64); err == nil {
+			attrs = append(attrs, slog.Int64("size", n))
+		}
+	}
+	var trackers []string
+	for _, p := range m.params {
+		if p.prefix == "tr" {
+			trackers = append(trackers, opts.tracker(p.value))
+		}
+	}
+	if len(trackers) > 0 {
+		attrs = append(attrs, slog.Any("trackers", trackers))
+	}
+	var experimental []any
+	for _, p := range m.params {
+		if p.prefix == "x." {
+			value := redacted
+			if opts.RevealExperimental {
+				value = p.value
+			}
+			experimental = append(experimental, slog.String(p.index, value))
+		}
+	}
+	if len(experimental) > 0 {
+		attrs = append(attrs, slog.Group("x", experimental...))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// tracker is what gets logged for the raw tr value.
+func (opts LogOptions) tracker(value string) string {
+	t, err := ParseTracker(value)
+	if err != nil {
+		//not something we can take apart, never log it verbatim
+		return redacted
+	}
+	if !opts.TrackerURLs {
+		return t.URL.Host
+	}
+	u := *t.URL
+	u.User = nil
+	if opts.RevealTrackerQuery {
+		return u.String()
+	}
+	if u.RawQuery != "" {
+		q := u.Query()
+		for k := range q {
+			q.Set(k, redacted)
+		}
+		u.RawQuery = q.Encode()
+	}
+	if match := passkeyPath.FindStringSubmatch(u.Path); match != nil {
+		u.Path = "/" + redacted + strings.TrimPrefix(u.Path, "/"+match[1])
+		u.RawPath = ""
+	}
+	if u.Fragment != "" {
+		u.Fragment, u.RawFragment = redacted, ""
+	}
+	return u.String()
+}
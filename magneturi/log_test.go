@@ -0,0 +1,63 @@
+package magneturi
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+)
+
+func TestMagnetURI_LogValue(t *testing.T) {
+	m := &MagnetURI{
+		params: []param{
+			param{"xt", "1", "urn:ed2k:354B15E68FB8F36D7CD88FF94116CDC1"},
+			param{"xt", "2", "urn:btih:QHQXPYWMACKDWKP47RRVIV7VOURXFE5Q"},
+			param{"xl", "", "10826029"},
+			param{"dn", "", "mediawiki-1.15.1.tar.gz"},
+			param{"tr", "", "http%3A%2F%2Ftracker.example.org%2Fannounce%3Fpasskey%3Dsecret"},
+			param{"tr", "", "udp://open.example.net:80#key=secret"},
+			param{"tr", "", "http://t.example/0123456789abcdef0123456789abcdef/announce"},
+			param{"x.", "Moz11", "private"},
+		},
+	}
+	tests := []struct {
+		name  string
+		value slog.LogValuer
+		want  string
+	}{
+		{
+			name:  "defaults",
+			value: m,
+			want: `level=INFO msg=added magnet.hash=btih:QHQXPYWMACKDWKP47RRVIV7VOURXFE5Q magnet.name=mediawiki-1.15.1.tar.gz magnet.size=10826029 ` +
+				`magnet.trackers="[tracker.example.org open.example.net:80 t.example]" magnet.x.Moz11=REDACTED` + "\n",
+		},
+		{
+			name:  "tracker urls",
+			value: m.Loggable(LogOptions{TrackerURLs: true}),
+			want: `level=INFO msg=added magnet.hash=btih:QHQXPYWMACKDWKP47RRVIV7VOURXFE5Q magnet.name=mediawiki-1.15.1.tar.gz magnet.size=10826029 ` +
+				`magnet.trackers="[http://tracker.example.org/announce?passkey=REDACTED udp://open.example.net:80#REDACTED http://t.example/REDACTED/announce]" magnet.x.Moz11=REDACTED` + "\n",
+		},
+		{
+			name:  "reveal everything",
+			value: m.Loggable(LogOptions{TrackerURLs: true, RevealTrackerQuery: true, RevealExperimental: true}),
+			want: `level=INFO msg=added magnet.hash=btih:QHQXPYWMACKDWKP47RRVIV7VOURXFE5Q magnet.name=mediawiki-1.15.1.tar.gz magnet.size=10826029 ` +
+				`magnet.trackers="[http://tracker.example.org/announce?passkey=secret udp://open.example.net:80#key=secret http://t.example/0123456789abcdef0123456789abcdef/announce]" magnet.x.Moz11=private` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+				ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+					if a.Key == slog.TimeKey && len(groups) == 0 {
+						return slog.Attr{}
+					}
+					return a
+				},
+			}))
+			logger.Info("added", "magnet", tt.value)
+			if got := buf.String(); got != tt.want {
+				t.Errorf("log output =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package magnetpb
+
+import (
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nmmh/magneturi/magneturi"
+)
+
+// digestEncodings is how the hash of each known algorithm is written
+// in a urn, see magneturi.ExactTopic.ValidHash.
+var digestEncodings = map[string]string{
+	"btih":       "hex",
+	"btmh":       "hex",
+	"ed2k":       "hex",
+	"md5":        "hex",
+	"sha1":       "base32",
+	"tree:tiger": "base32",
+	"aich":       "base32",
+	"bitprint":   "bitprint",
+}
+
+// sha1Size is the length of the SHA-1 half of a bitprint digest.
+const sha1Size = 20
+
+var base32NoPad = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// ToProto converts a link, filling in all fields of the message.
+func ToProto(m *magneturi.MagnetURI) *MagnetURI {
+	p := &MagnetURI{Raw: m.String()}
+	for _, param := range m.Params() {
+		p.Params = append(p.Params, &Parameter{Prefix: param.Prefix, Index: param.Index, Value: param.Value})
+		value := decode(param.Value)
+		switch param.Prefix {
+		case "tr":
+			p.Trackers = append(p.Trackers, &Tracker{Index: param.Index, Url: value})
+		case "xs", "as", "ws":
+			kind := map[string]Source_Kind{"xs": Source_KIND_EXACT, "as": Source_KIND_ACCEPTABLE, "ws": Source_KIND_WEB_SEED}[param.Prefix]
+			p.Sources = append(p.Sources, &Source{Kind: kind, Index: param.Index, Url: value})
+		case "x.":
+			if p.Experimental == nil {
+				p.Experimental = map[string]string{}
+			}
+			if _, ok := p.Experimental[param.Index]; !ok {
+				p.Experimental[param.Index] = value
+			}
+		case "dn":
+			if p.DisplayName == "" {
+				p.DisplayName = value
+			}
+		case "xl":
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil && p.ExactLength == 0 {
+				p.ExactLength = n
+			}
+		}
+	}
+	for _, t := range m.ExactTopics() {
+		p.ExactTopics = append(p.ExactTopics, &Topic{Index: t.Index, Algorithm: t.Namespace, Digest: digest(t), Urn: t.String()})
+	}
+	return p
+}
+
+// FromProto converts a message back to a link. The link is built from
+// Params when there are any, so that FromProto(ToProto(m)) serializes
+// exactly as m. Otherwise it is parsed from Raw, and if that is empty
+// as well assembled from the other fields.
+func FromProto(p *MagnetURI) (*magneturi.MagnetURI, error) {
+	if len(p.Params) > 0 {
+		var b strings.Builder
+		b.WriteString("magnet:?")
+		for i, param := range p.Params {
+			if strings.Contains(param.Value, "&") {
+				return nil, fmt.Errorf("magnetpb: parameter %d has an unescaped & in its value %q", i+1, param.Value)
+			}
+			if i > 0 {
+				b.WriteByte('&')
+			}
+			b.WriteString(magneturi.Param{Prefix: param.Prefix, Index: param.Index}.Key() + "=" + param.Value)
+		}
+		return parse(b.String())
+	}
+	if p.Raw != "" {
+		return parse(p.Raw)
+	}
+
+	var params []string
+	add := func(prefix, index, value string) {
+		params = append(params, magneturi.Param{Prefix: prefix, Index: index}.Key()+"="+value)
+	}
+	for _, t := range p.ExactTopics {
+		urn := t.Urn
+		if urn == "" {
+			hash, err := encodeDigest(t.Algorithm, t.Digest)
+			if err != nil {
+				return nil, err
+			}
+			urn = "urn:" + t.Algorithm + ":" + hash
+		}
+		add("xt", t.Index, urn)
+	}
+	if p.ExactLength > 0 {
+		add("xl", "", strconv.FormatInt(p.ExactLength, 10))
+	}
+	if p.DisplayName != "" {
+		add("dn", "", url.QueryEscape(p.DisplayName))
+	}
+	for _, t := range p.Trackers {
+		add("tr", t.Index, url.QueryEscape(t.Url))
+	}
+	for _, s := range p.Sources {
+		prefix, ok := map[Source_Kind]string{Source_KIND_EXACT: "xs", Source_KIND_ACCEPTABLE: "as", Source_KIND_WEB_SEED: "ws"}[s.Kind]
+		if !ok {
+			return nil, fmt.Errorf("magnetpb: source %q has the kind %v", s.Url, s.Kind)
+		}
+		add(prefix, s.Index, url.QueryEscape(s.Url))
+	}
+	names := make([]string, 0, len(p.Experimental))
+	for name := range p.Experimental {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		add("x.", name, url.QueryEscape(p.Experimental[name]))
+	}
+	return parse("magnet:?" + strings.Join(params, "&"))
+}
+
+func parse(raw string) (*magneturi.MagnetURI, error) {
+	m, err := magneturi.Parse(raw, false)
+	if err != nil {
+		return nil, fmt.Errorf("magnetpb: %w", err)
+	}
+	return m, nil
+}
+
+func decode(value string) string {
+	if decoded, err := url.QueryUnescape(value); err == nil {
+		return decoded
+	}
+	return value
+}
+
+// digest decodes the hash of a well formed topic of a known algorithm.
+func digest(t magneturi.ExactTopic) []byte {
+	if !t.ValidHash() {
+		return nil
+	}
+	hash := strings.ToUpper(t.Hash)
+	var b []byte
+	var err error
+	encoding := digestEncodings[t.Namespace]
+	// btih and sha1 hashes are written in either encoding
+	switch {
+	case t.Namespace == "btih" && len(hash) == 32:
+		encoding = "base32"
+	case t.Namespace == "sha1" && len(hash) == 40:
+		encoding = "hex"
+	}
+	switch encoding {
+	case "bitprint":
+		sha1, tiger, _ := strings.Cut(hash, ".")
+		var tb []byte
+		if b, err = base32NoPad.DecodeString(sha1); err == nil {
+			tb, err = base32NoPad.DecodeString(tiger)
+			b = append(b, tb...)
+		}
+	case "hex":
+		b, err = hex.DecodeString(hash)
+	default:
+		b, err = base32NoPad.DecodeString(hash)
+	}
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+// encodeDigest writes a digest the way topics of the algorithm are
+// usually written.
+func encodeDigest(algorithm string, digest []byte) (string, error) {
+	switch digestEncodings[algorithm] {
+	case "hex":
+		return hex.EncodeToString(digest), nil
+	case "base32":
+		return base32NoPad.EncodeToString(digest), nil
+	case "bitprint":
+		if len(digest) <= sha1Size {
+			return "", fmt.Errorf("magnetpb: bitprint digest of %d bytes", len(digest))
+		}
+		return base32NoPad.EncodeToString(digest[:sha1Size]) + "." + base32NoPad.EncodeToString(digest[sha1Size:]), nil
+	}
+	return "", fmt.Errorf("magnetpb: topic of the unknown algorithm %q has no urn", algorithm)
+}
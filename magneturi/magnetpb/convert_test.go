@@ -0,0 +1,178 @@
+package magnetpb
+
+import (
+	"encoding/hex"
+	"reflect"
+	"testing"
+
+	"github.com/nmmh/magneturi/magneturi"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const demo = "magnet:?xt.1=urn:ed2k:354B15E68FB8F36D7CD88FF94116CDC1&xt.2=urn:tree:tiger:7N5OAMRNGMSSEUE3ORHOKWN4WWIQ5X4EBOOTLJY&xt.3=urn:btih:QHQXPYWMACKDWKP47RRVIV7VOURXFE5Q&xl=10826029&dn=mediawiki-1.15.1.tar.gz&tr=udp%3A%2F%2Ftracker.openbittorrent.com%3A80%2Fannounce&as=http%3A%2F%2Fdownload.wikimedia.org%2Fmediawiki%2F1.15%2Fmediawiki-1.15.1.tar.gz&xs=http%3A%2F%2Fcache.example.org%2FXRX2PEFXOOEJFRVUCX6HMZMKS5TWG4K5&xs=dchub://example.org&x.Moz11=test"
+
+func TestToProto(t *testing.T) {
+	p := ToProto(magneturi.MustParse(demo))
+	if p.Raw != demo || len(p.Params) != 10 {
+		t.Errorf("ToProto() Raw = %q with %d params", p.Raw, len(p.Params))
+	}
+	wantTopics := []*Topic{
+		{Index: "1", Algorithm: "ed2k", Digest: mustHex("354B15E68FB8F36D7CD88FF94116CDC1"), Urn: "urn:ed2k:354B15E68FB8F36D7CD88FF94116CDC1"},
+		{Index: "2", Algorithm: "tree:tiger", Digest: mustHex("fb7ae0322d332522509b744ee559bcb5910edf840b9d35a7"), Urn: "urn:tree:tiger:7N5OAMRNGMSSEUE3ORHOKWN4WWIQ5X4EBOOTLJY"},
+		{Index: "3", Algorithm: "btih", Digest: mustHex("81e177e2cc00943b29fcfc635457f575237293b0"), Urn: "urn:btih:QHQXPYWMACKDWKP47RRVIV7VOURXFE5Q"},
+	}
+	if !equal(p.ExactTopics, wantTopics) {
+		t.Errorf("ToProto() ExactTopics = %v, want %v", p.ExactTopics, wantTopics)
+	}
+	if want := []*Tracker{{Url: "udp://tracker.openbittorrent.com:80/announce"}}; !equal(p.Trackers, want) {
+		t.Errorf("ToProto() Trackers = %v, want %v", p.Trackers, want)
+	}
+	wantSources := []*Source{
+		{Kind: Source_KIND_ACCEPTABLE, Url: "http://download.wikimedia.org/mediawiki/1.15/mediawiki-1.15.1.tar.gz"},
+		{Kind: Source_KIND_EXACT, Url: "http://cache.example.org/XRX2PEFXOOEJFRVUCX6HMZMKS5TWG4K5"},
+		{Kind: Source_KIND_EXACT, Url: "dchub://example.org"},
+	}
+	if !equal(p.Sources, wantSources) {
+		t.Errorf("ToProto() Sources = %v, want %v", p.Sources, wantSources)
+	}
+	if p.DisplayName != "mediawiki-1.15.1.tar.gz" || p.ExactLength != 10826029 ||
+		!reflect.DeepEqual(p.Experimental, map[string]string{"Moz11": "test"}) {
+		t.Errorf("ToProto() = %+v", p)
+	}
+}
+
+func TestFromProto(t *testing.T) {
+	tests := []struct {
+		name    string
+		p       *MagnetURI
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "params win over the other fields",
+			p:    &MagnetURI{Params: []*Parameter{{Prefix: "dn", Index: "", Value: "a"}, {Prefix: "x.", Index: "pe", Value: "1.2.3.4:5"}}, Raw: "magnet:?dn=b", DisplayName: "c"},
+			want: "magnet:?dn=a&x.pe=1.2.3.4:5",
+		},
+		{
+			name: "raw",
+			p:    &MagnetURI{Raw: "magnet:?dn=b", DisplayName: "c"},
+			want: "magnet:?dn=b",
+		},
+		{
+			name: "fields",
+			p: &MagnetURI{
+				ExactTopics: []*Topic{
+					{Index: "1", Algorithm: "btih", Digest: mustHex("81e177e2cc00943b29fcfc635457f575237293b0")},
+					{Index: "2", Algorithm: "sha1", Digest: mustHex("81e177e2cc00943b29fcfc635457f575237293b0")},
+					{Index: "3", Algorithm: "ed2k", Urn: "urn:ed2k:354B15E68FB8F36D7CD88FF94116CDC1"},
+				},
+				ExactLength:  3,
+				DisplayName:  "a b",
+				Trackers:     []*Tracker{{Index: "1", Url: "http://a/announce?k=v"}},
+				Sources:      []*Source{{Kind: Source_KIND_WEB_SEED, Url: "http://s/"}},
+				Experimental: map[string]string{"pe": "1.2.3.4:5"},
+			},
+			want: "magnet:?xt.1=urn:btih:81e177e2cc00943b29fcfc635457f575237293b0&xt.2=urn:sha1:QHQXPYWMACKDWKP47RRVIV7VOURXFE5Q&xt.3=urn:ed2k:354B15E68FB8F36D7CD88FF94116CDC1" +
+				"&xl=3&dn=a+b&tr.1=http%3A%2F%2Fa%2Fannounce%3Fk%3Dv&ws=http%3A%2F%2Fs%2F&x.pe=1.2.3.4%3A5",
+		},
+		{
+			name: "empty",
+			p:    &MagnetURI{},
+			want: "magnet:?",
+		},
+		{
+			name:    "unescaped ampersand",
+			p:       &MagnetURI{Params: []*Parameter{{Prefix: "dn", Index: "", Value: "a&tr=x"}}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid prefix",
+			p:       &MagnetURI{Params: []*Parameter{{Prefix: "zz", Index: "", Value: "a"}}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown algorithm without urn",
+			p:       &MagnetURI{ExactTopics: []*Topic{{Algorithm: "crc32", Digest: []byte{1}}}},
+			wantErr: true,
+		},
+		{
+			name:    "source without kind",
+			p:       &MagnetURI{Sources: []*Source{{Url: "http://s/"}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromProto(tt.p)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromProto() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.String() != tt.want {
+				t.Errorf("FromProto() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, raw := range []string{
+		demo,
+		"magnet:?",
+		"magnet:?xt=urn:bitprint:QHQXPYWMACKDWKP47RRVIV7VOURXFE5Q.7N5OAMRNGMSSEUE3ORHOKWN4WWIQ5X4EBOOTLJY&tr.1=http://a&tr.1=http://b&tr.2=udp://c:80&kt=a+b",
+	} {
+		m := magneturi.MustParse(raw)
+		b, err := proto.Marshal(ToProto(m))
+		if err != nil {
+			t.Fatalf("proto.Marshal() error = %v", err)
+		}
+		var p MagnetURI
+		if err := proto.Unmarshal(b, &p); err != nil {
+			t.Fatalf("proto.Unmarshal() error = %v", err)
+		}
+		got, err := FromProto(&p)
+		if err != nil {
+			t.Fatalf("FromProto() error = %v", err)
+		}
+		if got.String() != m.String() {
+			t.Errorf("round trip = %s, want %s", got, m)
+		}
+		j, err := protojson.Marshal(&p)
+		if err != nil {
+			t.Fatalf("protojson.Marshal() error = %v", err)
+		}
+		var pj MagnetURI
+		if err := protojson.Unmarshal(j, &pj); err != nil {
+			t.Fatalf("protojson.Unmarshal() error = %v", err)
+		}
+		if !proto.Equal(&pj, &p) {
+			t.Errorf("protojson round trip = %v, want %v", &pj, &p)
+		}
+		// Without params the link is rebuilt from the other fields.
+		p.Params, p.Raw = nil, ""
+		if _, err := FromProto(&p); err != nil {
+			t.Errorf("FromProto() without params and raw, error = %v", err)
+		}
+	}
+}
+
+// equal compares lists of messages with proto.Equal.
+func equal[M proto.Message](got, want []M) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if !proto.Equal(got[i], want[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
@@ -0,0 +1,7 @@
+// Package magnetpb is the protobuf interchange format of magnet links,
+// defined in magnet.proto. The message types in magnet.pb.go are
+// generated by protoc-gen-go, ToProto and FromProto convert between
+// them and magneturi.MagnetURI.
+package magnetpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative magnet.proto
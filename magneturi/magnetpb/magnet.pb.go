@@ -0,0 +1,539 @@
+// Interchange format of magnet links.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.7
+// 	protoc        (unknown)
+// source: magnet.proto
+
+package magnetpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Source_Kind int32
+
+const (
+	Source_KIND_UNSPECIFIED Source_Kind = 0
+	Source_KIND_EXACT       Source_Kind = 1
+	Source_KIND_ACCEPTABLE  Source_Kind = 2
+	Source_KIND_WEB_SEED    Source_Kind = 3
+)
+
+// Enum value maps for Source_Kind.
+var (
+	Source_Kind_name = map[int32]string{
+		0: "KIND_UNSPECIFIED",
+		1: "KIND_EXACT",
+		2: "KIND_ACCEPTABLE",
+		3: "KIND_WEB_SEED",
+	}
+	Source_Kind_value = map[string]int32{
+		"KIND_UNSPECIFIED": 0,
+		"KIND_EXACT":       1,
+		"KIND_ACCEPTABLE":  2,
+		"KIND_WEB_SEED":    3,
+	}
+)
+
+func (x Source_Kind) Enum() *Source_Kind {
+	p := new(Source_Kind)
+	*p = x
+	return p
+}
+
+func (x Source_Kind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Source_Kind) Descriptor() protoreflect.EnumDescriptor {
+	return file_magnet_proto_enumTypes[0].Descriptor()
+}
+
+func (Source_Kind) Type() protoreflect.EnumType {
+	return &file_magnet_proto_enumTypes[0]
+}
+
+func (x Source_Kind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Source_Kind.Descriptor instead.
+func (Source_Kind) EnumDescriptor() ([]byte, []int) {
+	return file_magnet_proto_rawDescGZIP(), []int{4, 0}
+}
+
+// A magnet link. params is the lossless form of the link, the other
+// fields are views of it for consumers that do not parse magnet links.
+type MagnetURI struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The parameters in link order.
+	Params []*Parameter `protobuf:"bytes,1,rep,name=params,proto3" json:"params,omitempty"`
+	// The exact topics (xt).
+	ExactTopics []*Topic `protobuf:"bytes,2,rep,name=exact_topics,json=exactTopics,proto3" json:"exact_topics,omitempty"`
+	// The trackers (tr), decoded.
+	Trackers []*Tracker `protobuf:"bytes,3,rep,name=trackers,proto3" json:"trackers,omitempty"`
+	// The exact sources (xs), acceptable sources (as) and web seeds (ws),
+	// decoded.
+	Sources []*Source `protobuf:"bytes,4,rep,name=sources,proto3" json:"sources,omitempty"`
+	// The experimental parameters (x.<name>), the first value of each.
+	Experimental map[string]string `protobuf:"bytes,5,rep,name=experimental,proto3" json:"experimental,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// The first display name (dn), decoded.
+	DisplayName string `protobuf:"bytes,6,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+	// The first exact length (xl).
+	ExactLength int64 `protobuf:"varint,7,opt,name=exact_length,json=exactLength,proto3" json:"exact_length,omitempty"`
+	// The link as a URI.
+	Raw           string `protobuf:"bytes,8,opt,name=raw,proto3" json:"raw,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MagnetURI) Reset() {
+	*x = MagnetURI{}
+	mi := &file_magnet_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MagnetURI) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MagnetURI) ProtoMessage() {}
+
+func (x *MagnetURI) ProtoReflect() protoreflect.Message {
+	mi := &file_magnet_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MagnetURI.ProtoReflect.Descriptor instead.
+func (*MagnetURI) Descriptor() ([]byte, []int) {
+	return file_magnet_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *MagnetURI) GetParams() []*Parameter {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+func (x *MagnetURI) GetExactTopics() []*Topic {
+	if x != nil {
+		return x.ExactTopics
+	}
+	return nil
+}
+
+func (x *MagnetURI) GetTrackers() []*Tracker {
+	if x != nil {
+		return x.Trackers
+	}
+	return nil
+}
+
+func (x *MagnetURI) GetSources() []*Source {
+	if x != nil {
+		return x.Sources
+	}
+	return nil
+}
+
+func (x *MagnetURI) GetExperimental() map[string]string {
+	if x != nil {
+		return x.Experimental
+	}
+	return nil
+}
+
+func (x *MagnetURI) GetDisplayName() string {
+	if x != nil {
+		return x.DisplayName
+	}
+	return ""
+}
+
+func (x *MagnetURI) GetExactLength() int64 {
+	if x != nil {
+		return x.ExactLength
+	}
+	return 0
+}
+
+func (x *MagnetURI) GetRaw() string {
+	if x != nil {
+		return x.Raw
+	}
+	return ""
+}
+
+// A parameter as it appears in the URI, tr.1=udp%3A%2F%2Fexample.org
+// has the prefix "tr", the index "1" and the value
+// "udp%3A%2F%2Fexample.org". Experimental parameters have the prefix
+// "x." and their name as the index.
+type Parameter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Prefix        string                 `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	Index         string                 `protobuf:"bytes,2,opt,name=index,proto3" json:"index,omitempty"`
+	Value         string                 `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Parameter) Reset() {
+	*x = Parameter{}
+	mi := &file_magnet_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Parameter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Parameter) ProtoMessage() {}
+
+func (x *Parameter) ProtoReflect() protoreflect.Message {
+	mi := &file_magnet_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Parameter.ProtoReflect.Descriptor instead.
+func (*Parameter) Descriptor() ([]byte, []int) {
+	return file_magnet_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Parameter) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *Parameter) GetIndex() string {
+	if x != nil {
+		return x.Index
+	}
+	return ""
+}
+
+func (x *Parameter) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+// An exact topic urn:<algorithm>:<hash>.
+type Topic struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Index string                 `protobuf:"bytes,1,opt,name=index,proto3" json:"index,omitempty"`
+	// The urn namespace, e.g. "btih" or "tree:tiger".
+	Algorithm string `protobuf:"bytes,2,opt,name=algorithm,proto3" json:"algorithm,omitempty"`
+	// The hash, decoded from its hex or base32 form. Empty for hashes
+	// that are not well formed for a known algorithm.
+	Digest []byte `protobuf:"bytes,3,opt,name=digest,proto3" json:"digest,omitempty"`
+	// The topic as it appears in the link.
+	Urn           string `protobuf:"bytes,4,opt,name=urn,proto3" json:"urn,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Topic) Reset() {
+	*x = Topic{}
+	mi := &file_magnet_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Topic) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Topic) ProtoMessage() {}
+
+func (x *Topic) ProtoReflect() protoreflect.Message {
+	mi := &file_magnet_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Topic.ProtoReflect.Descriptor instead.
+func (*Topic) Descriptor() ([]byte, []int) {
+	return file_magnet_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Topic) GetIndex() string {
+	if x != nil {
+		return x.Index
+	}
+	return ""
+}
+
+func (x *Topic) GetAlgorithm() string {
+	if x != nil {
+		return x.Algorithm
+	}
+	return ""
+}
+
+func (x *Topic) GetDigest() []byte {
+	if x != nil {
+		return x.Digest
+	}
+	return nil
+}
+
+func (x *Topic) GetUrn() string {
+	if x != nil {
+		return x.Urn
+	}
+	return ""
+}
+
+type Tracker struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The tr.N index, the BEP 12 tier.
+	Index         string `protobuf:"bytes,1,opt,name=index,proto3" json:"index,omitempty"`
+	Url           string `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Tracker) Reset() {
+	*x = Tracker{}
+	mi := &file_magnet_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Tracker) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Tracker) ProtoMessage() {}
+
+func (x *Tracker) ProtoReflect() protoreflect.Message {
+	mi := &file_magnet_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Tracker.ProtoReflect.Descriptor instead.
+func (*Tracker) Descriptor() ([]byte, []int) {
+	return file_magnet_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Tracker) GetIndex() string {
+	if x != nil {
+		return x.Index
+	}
+	return ""
+}
+
+func (x *Tracker) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+type Source struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Kind          Source_Kind            `protobuf:"varint,1,opt,name=kind,proto3,enum=magneturi.v1.Source_Kind" json:"kind,omitempty"`
+	Index         string                 `protobuf:"bytes,2,opt,name=index,proto3" json:"index,omitempty"`
+	Url           string                 `protobuf:"bytes,3,opt,name=url,proto3" json:"url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Source) Reset() {
+	*x = Source{}
+	mi := &file_magnet_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Source) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Source) ProtoMessage() {}
+
+func (x *Source) ProtoReflect() protoreflect.Message {
+	mi := &file_magnet_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Source.ProtoReflect.Descriptor instead.
+func (*Source) Descriptor() ([]byte, []int) {
+	return file_magnet_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Source) GetKind() Source_Kind {
+	if x != nil {
+		return x.Kind
+	}
+	return Source_KIND_UNSPECIFIED
+}
+
+func (x *Source) GetIndex() string {
+	if x != nil {
+		return x.Index
+	}
+	return ""
+}
+
+func (x *Source) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+var File_magnet_proto protoreflect.FileDescriptor
+
+const file_magnet_proto_rawDesc = "" +
+	"\n" +
+	"\fmagnet.proto\x12\fmagneturi.v1\"\xbf\x03\n" +
+	"\tMagnetURI\x12/\n" +
+	"\x06params\x18\x01 \x03(\v2\x17.magneturi.v1.ParameterR\x06params\x126\n" +
+	"\fexact_topics\x18\x02 \x03(\v2\x13.magneturi.v1.TopicR\vexactTopics\x121\n" +
+	"\btrackers\x18\x03 \x03(\v2\x15.magneturi.v1.TrackerR\btrackers\x12.\n" +
+	"\asources\x18\x04 \x03(\v2\x14.magneturi.v1.SourceR\asources\x12M\n" +
+	"\fexperimental\x18\x05 \x03(\v2).magneturi.v1.MagnetURI.ExperimentalEntryR\fexperimental\x12!\n" +
+	"\fdisplay_name\x18\x06 \x01(\tR\vdisplayName\x12!\n" +
+	"\fexact_length\x18\a \x01(\x03R\vexactLength\x12\x10\n" +
+	"\x03raw\x18\b \x01(\tR\x03raw\x1a?\n" +
+	"\x11ExperimentalEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"O\n" +
+	"\tParameter\x12\x16\n" +
+	"\x06prefix\x18\x01 \x01(\tR\x06prefix\x12\x14\n" +
+	"\x05index\x18\x02 \x01(\tR\x05index\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\tR\x05value\"e\n" +
+	"\x05Topic\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\tR\x05index\x12\x1c\n" +
+	"\talgorithm\x18\x02 \x01(\tR\talgorithm\x12\x16\n" +
+	"\x06digest\x18\x03 \x01(\fR\x06digest\x12\x10\n" +
+	"\x03urn\x18\x04 \x01(\tR\x03urn\"1\n" +
+	"\aTracker\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\tR\x05index\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\"\xb5\x01\n" +
+	"\x06Source\x12-\n" +
+	"\x04kind\x18\x01 \x01(\x0e2\x19.magneturi.v1.Source.KindR\x04kind\x12\x14\n" +
+	"\x05index\x18\x02 \x01(\tR\x05index\x12\x10\n" +
+	"\x03url\x18\x03 \x01(\tR\x03url\"T\n" +
+	"\x04Kind\x12\x14\n" +
+	"\x10KIND_UNSPECIFIED\x10\x00\x12\x0e\n" +
+	"\n" +
+	"KIND_EXACT\x10\x01\x12\x13\n" +
+	"\x0fKIND_ACCEPTABLE\x10\x02\x12\x11\n" +
+	"\rKIND_WEB_SEED\x10\x03B.Z,github.com/nmmh/magneturi/magneturi/magnetpbb\x06proto3"
+
+var (
+	file_magnet_proto_rawDescOnce sync.Once
+	file_magnet_proto_rawDescData []byte
+)
+
+func file_magnet_proto_rawDescGZIP() []byte {
+	file_magnet_proto_rawDescOnce.Do(func() {
+		file_magnet_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_magnet_proto_rawDesc), len(file_magnet_proto_rawDesc)))
+	})
+	return file_magnet_proto_rawDescData
+}
+
+var file_magnet_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_magnet_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_magnet_proto_goTypes = []any{
+	(Source_Kind)(0),  // 0: magneturi.v1.Source.Kind
+	(*MagnetURI)(nil), // 1: magneturi.v1.MagnetURI
+	(*Parameter)(nil), // 2: magneturi.v1.Parameter
+	(*Topic)(nil),     // 3: magneturi.v1.Topic
+	(*Tracker)(nil),   // 4: magneturi.v1.Tracker
+	(*Source)(nil),    // 5: magneturi.v1.Source
+	nil,               // 6: magneturi.v1.MagnetURI.ExperimentalEntry
+}
+var file_magnet_proto_depIdxs = []int32{
+	2, // 0: magneturi.v1.MagnetURI.params:type_name -> magneturi.v1.Parameter
+	3, // 1: magneturi.v1.MagnetURI.exact_topics:type_name -> magneturi.v1.Topic
+	4, // 2: magneturi.v1.MagnetURI.trackers:type_name -> magneturi.v1.Tracker
+	5, // 3: magneturi.v1.MagnetURI.sources:type_name -> magneturi.v1.Source
+	6, // 4: magneturi.v1.MagnetURI.experimental:type_name -> magneturi.v1.MagnetURI.ExperimentalEntry
+	0, // 5: magneturi.v1.Source.kind:type_name -> magneturi.v1.Source.Kind
+	6, // [6:6] is the sub-list for method output_type
+	6, // [6:6] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_magnet_proto_init() }
+func file_magnet_proto_init() {
+	if File_magnet_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_magnet_proto_rawDesc), len(file_magnet_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_magnet_proto_goTypes,
+		DependencyIndexes: file_magnet_proto_depIdxs,
+		EnumInfos:         file_magnet_proto_enumTypes,
+		MessageInfos:      file_magnet_proto_msgTypes,
+	}.Build()
+	File_magnet_proto = out.File
+	file_magnet_proto_goTypes = nil
+	file_magnet_proto_depIdxs = nil
+}
@@ -3,9 +3,7 @@ package magneturi
 import (
 	"fmt"
 	"os"
-	"strconv"
 	"strings"
-	"text/tabwriter"
 )
 
 const (
@@ -22,6 +20,7 @@ var paramType = func() map[string]string {
 		"xs": "exactSource",
 		"as": "acceptableSource",
 		"xl": "exactLength",
+		"ws": "webSeed",
 		"x.": "experimental",
 	}
 }
@@ -44,6 +43,10 @@ func Parse(rawMagnetURI string, softParse bool) (*MagnetURI, error) {
 	m := &MagnetURI{}
 	if strings.HasPrefix(rawMagnetURI, magnetSchemaPrefix) {
 		magnetNoSchemaPrefix := strings.TrimPrefix(rawMagnetURI, magnetSchemaPrefix)
+		if magnetNoSchemaPrefix == "" {
+			//"magnet:?" is what an empty MagnetURI serializes to
+			return m, nil
+		}
 		params := strings.Split(magnetNoSchemaPrefix, "&")
 		for _, param := range params {
 			validParam, err := parseParam(param)
@@ -66,13 +69,13 @@ func Parse(rawMagnetURI string, softParse bool) (*MagnetURI, error) {
 		}
 		return m, nil
 	}
-	return m, fmt.Errorf("uri doesn't start with the Magnet URI schema prefix %q", magnetSchemaPrefix)
+	return m, parseErrorf(KindScheme, rawMagnetURI, "uri doesn't start with the Magnet URI schema prefix %q", magnetSchemaPrefix)
 }
 
 func parseParam(parameter string) (param, error) {
 	paramSplit := strings.SplitN(parameter, "=", 2)
 	if len(paramSplit) != 2 || (len(paramSplit) == 2 && paramSplit[1] == "") {
-		return param{}, fmt.Errorf("parameter without prefix or prefix without parameter: %q", parameter)
+		return param{}, parseErrorf(KindSyntax, parameter, "parameter without prefix or prefix without parameter: %q", parameter)
 	}
 	prefix := paramSplit[0]
 	prefix, index, err := splitDotPrefix(prefix)
@@ -80,7 +83,7 @@ func parseParam(parameter string) (param, error) {
 		return param{}, err
 	}
 	if !isValidPrefix(prefix) {
-		return param{}, fmt.Errorf("invalid parameter prefix: %q", prefix)
+		return param{}, parseErrorf(KindPrefix, parameter, "invalid parameter prefix: %q", prefix)
 	}
 	value := paramSplit[1]
 	return param{prefix, index, value}, nil
@@ -90,13 +93,13 @@ func splitDotPrefix(prefix string) (string, string, error) {
 	if strings.HasPrefix(prefix, "x.") {
 		exp := strings.TrimLeft(prefix, "x.")
 		if exp == "" {
-			return "", "", fmt.Errorf("experimental info missing: %q", prefix)
+			return "", "", parseErrorf(KindIndex, prefix, "experimental info missing: %q", prefix)
 		}
 		return "x.", exp, nil
 	} else if strings.Contains(prefix, ".") {
 		prefixSplit := strings.SplitN(prefix, ".", 2)
 		if len(prefixSplit) != 2 || (len(prefixSplit) == 2 && prefixSplit[1] == "") {
-			return "", "", fmt.Errorf("dot index missing: %q", prefix)
+			return "", "", parseErrorf(KindIndex, prefix, "dot index missing: %q", prefix)
 		}
 		index := prefixSplit[1]
 		return prefixSplit[0], index, nil
@@ -152,7 +155,7 @@ func isValidPrefix(prefix string) bool {
 
 func (m *MagnetURI) addParam(validParam param) error {
 	if !isValidPrefix(validParam.prefix) {
-		return fmt.Errorf("invalid parameter prefix: %q", validParam.prefix)
+		return parseErrorf(KindPrefix, validParam.prefix, "invalid parameter prefix: %q", validParam.prefix)
 	}
 	m.params = append(m.params, validParam)
 	return nil
@@ -182,6 +185,18 @@ func (m *MagnetURI) HasPrefix(prefix string) bool {
 	return false
 }
 
+// Experimental returns the values of the experimental parameter
+// x.<name>, e.g. Experimental("pe") for the x.pe peer addresses.
+func (m *MagnetURI) Experimental(name string) []string {
+	var values []string
+	for _, p := range m.params {
+		if p.prefix == "x." && p.index == name {
+			values = append(values, p.value)
+		}
+	}
+	return values
+}
+
 //HasPrefixes return false if it does not have a parameter with
 // one of the supplied prefixes
 func (m *MagnetURI) HasPrefixes(paramTypes ...string) bool {
@@ -198,30 +213,41 @@ func (m *MagnetURI) Equal(x MagnetURI) bool {
 	return compareParams(m.params, x.params)
 }
 
-// Asembles from struct
+// String assembles the URI from the parameters. It always returns a
+// parseable URI, an empty MagnetURI is serialized as "magnet:?".
+// Use Describe for a human readable summary.
 func (m *MagnetURI) String() string {
+	var ret string
+	for _, p := range m.params {
+		ret += "&" + paramKey(p) + "=" + p.value
+	}
+	s := fmt.Sprintf("%s%s", magnetSchemaPrefix, strings.TrimLeft(ret, "&"))
+	return s
+}
+
+// Describe returns a short human readable summary of the parameters,
+// it is not a URI and must not be stored as one.
+func (m *MagnetURI) Describe() string {
 	if len(m.params) == 0 {
 		return "the Magnet URI has no parameters"
 	}
-	var ret string
+	var order []string
+	counts := map[string]int{}
 	for _, p := range m.params {
-		if p.index != "" {
-			ret += "&" + strings.TrimRight(p.prefix, ".") + "." + p.index + "=" + p.value
-		} else {
-			ret += "&" + p.prefix + "=" + p.value
+		if counts[p.prefix] == 0 {
+			order = append(order, p.prefix)
 		}
+		counts[p.prefix]++
 	}
-	s := fmt.Sprintf("%s%s", magnetSchemaPrefix, strings.TrimLeft(ret, "&"))
-	return s
+	parts := make([]string, 0, len(order))
+	for _, prefix := range order {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[prefix], paramType()[prefix]))
+	}
+	return fmt.Sprintf("Magnet URI with %d parameters: %s", len(m.params), strings.Join(parts, ", "))
 }
 
 //PrintVerbose pretty prints some info.
+// It is WriteVerbose to os.Stdout with the default table format.
 func (m *MagnetURI) PrintVerbose() {
-	tw := tabwriter.NewWriter(os.Stdout, 0, 1, 1, ' ', tabwriter.TabIndent)
-	fmt.Fprintln(tw, "#\tPrefix\tIndex/Exp\tDescription\tValue")
-	fmt.Fprintln(tw, "=\t======\t=========\t===========\t=====")
-	for i, p := range m.params {
-		fmt.Fprintln(tw, strconv.Itoa(i)+"\t"+p.prefix+"\t"+p.index+"\t"+paramType()[p.prefix]+"\t"+p.value)
-	}
-	tw.Flush()
+	m.WriteVerbose(os.Stdout, VerboseOptions{})
 }
@@ -319,7 +319,7 @@ func TestMagnetURI_String(t *testing.T) {
 			m: MagnetURI{
 				params: []param{},
 			},
-			want: "the Magnet URI has no parameters",
+			want: "magnet:?",
 		},
 	}
 
@@ -332,6 +332,53 @@ func TestMagnetURI_String(t *testing.T) {
 	}
 }
 
+func TestMagnetURI_StringRoundTrip(t *testing.T) {
+	for _, raw := range []string{
+		"magnet:?",
+		"magnet:?xt.1=urn:ed2k:354B15E68FB8F36D7CD88FF94116CDC1&dn=mediawiki-1.15.1.tar.gz&x.Moz11=test",
+	} {
+		m, err := Parse(raw, false)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", raw, err)
+		}
+		if got := m.String(); got != raw {
+			t.Errorf("MagnetURI.String() = %v, want %v", got, raw)
+		}
+	}
+}
+
+func TestMagnetURI_Describe(t *testing.T) {
+	tests := []struct {
+		name string
+		m    MagnetURI
+		want string
+	}{
+		{
+			name: "Describe params",
+			m: MagnetURI{
+				params: []param{
+					param{"xt", "1", "urn:ed2k:354B15E68FB8F36D7CD88FF94116CDC1"},
+					param{"dn", "", "mediawiki-1.15.1.tar.gz"},
+					param{"xt", "2", "urn:tree:tiger:7N5OAMRNGMSSEUE3ORHOKWN4WWIQ5X4EBOOTLJY"},
+				},
+			},
+			want: "Magnet URI with 3 parameters: 2 exactTopic, 1 displayName",
+		},
+		{
+			name: "Describe no params",
+			m:    MagnetURI{},
+			want: "the Magnet URI has no parameters",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.m.Describe(); got != tt.want {
+				t.Errorf("MagnetURI.Describe() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestMagnetURI_Filter(t *testing.T) {
 	type args struct {
 		paramTypes []string
@@ -454,6 +501,22 @@ func TestMagnetURI_HasPrefix(t *testing.T) {
 	}
 }
 
+func TestMagnetURI_Experimental(t *testing.T) {
+	m := &MagnetURI{
+		params: []param{
+			param{"x.", "pe", "10.0.0.1:6881"},
+			param{"x.", "Moz11", "test"},
+			param{"x.", "pe", "example.org:51413"},
+		},
+	}
+	if got, want := m.Experimental("pe"), []string{"10.0.0.1:6881", "example.org:51413"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MagnetURI.Experimental() = %v, want %v", got, want)
+	}
+	if got := m.Experimental("kt"); got != nil {
+		t.Errorf("MagnetURI.Experimental() = %v, want nil", got)
+	}
+}
+
 func TestMagnetURI_getParamsByPrefix(t *testing.T) {
 	type args struct {
 		prefix string
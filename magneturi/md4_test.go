@@ -0,0 +1,30 @@
+package magneturi
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func Test_md4(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", "31d6cfe0d16ae931b73c59d7e0c089c0"},
+		{"a", "bde52cb31de33e46245e05fbdbd6fb24"},
+		{"abc", "a448017aaf21d8525fc10ae87aa6729d"},
+		{"message digest", "d9130a8164549fe818874806e1c7014b"},
+		{"abcdefghijklmnopqrstuvwxyz", "d79e1c308aa5bbcdeea8ed63df412da9"},
+		{strings.Repeat("1234567890", 8), "e33b4ddc9c38f2199c3e7b164fcc0536"},
+	}
+	for _, tt := range tests {
+		d := newMD4()
+		//split writes to exercise the block buffering
+		d.Write([]byte(tt.in[:len(tt.in)/3]))
+		d.Write([]byte(tt.in[len(tt.in)/3:]))
+		if got := hex.EncodeToString(d.Sum(nil)); got != tt.want {
+			t.Errorf("md4(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
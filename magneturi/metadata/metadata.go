@@ -0,0 +1,293 @@
+// Package metadata resolves a magnet link into the info dictionary of
+// its torrent by downloading it from peers with the BEP 9 ut_metadata
+// extension of the BEP 10 extension protocol.
+package metadata
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/nmmh/magneturi/magneturi"
+	"github.com/nmmh/magneturi/magneturi/bencode"
+)
+
+const (
+	protocol = "\x13BitTorrent protocol"
+	// PieceSize is the size of every metadata piece but the last.
+	PieceSize = 16 * 1024
+	// DefaultMaxSize caps the metadata size a peer may announce.
+	DefaultMaxSize = 8 << 20
+	// DefaultTimeout is how long one peer gets to deliver.
+	DefaultTimeout = 30 * time.Second
+
+	msgExtended = 20
+	// extHandshake is the extended message id of the handshake,
+	// utMetadata the id we ask peers to use for ut_metadata.
+	extHandshake = 0
+	utMetadata   = 1
+
+	metadataRequest = 0
+	metadataData    = 1
+	metadataReject  = 2
+
+	// maxMessage is a PieceSize piece plus room for its header.
+	maxMessage = PieceSize + 1024
+)
+
+// ErrNoPeers is returned when there is no peer to ask.
+var ErrNoPeers = errors.New("metadata: no peers")
+
+// Fetcher downloads metadata, the zero value is ready to use.
+type Fetcher struct {
+	// PeerID identifies us, a random one is used if it is zero.
+	PeerID [20]byte
+	// Timeout limits the time spent on one peer, DefaultTimeout if 0.
+	Timeout time.Duration
+	// MaxSize caps the accepted metadata size, DefaultMaxSize if 0.
+	MaxSize int
+}
+
+// Fetch returns the bencoded info dictionary of the torrent with the
+// first btih info-hash of m, verified against that hash. Peers are
+// tried one after the other, the given ones first and then those in
+// the x.pe parameters of m.
+func (f *Fetcher) Fetch(ctx context.Context, m *magneturi.MagnetURI, peers ...string) ([]byte, error) {
+	hashes := m.InfoHashes()
+	if len(hashes) == 0 {
+		return nil, fmt.Errorf("metadata: magnet uri has no btih exact topic")
+	}
+	for _, pe := range m.Experimental("pe") {
+		if decoded, err := url.QueryUnescape(pe); err == nil {
+			pe = decoded
+		}
+		peers = append(peers, pe)
+	}
+	if len(peers) == 0 {
+		return nil, ErrNoPeers
+	}
+	var errs []error
+	for _, addr := range peers {
+		info, err := f.FetchFrom(ctx, addr, hashes[0])
+		if err == nil {
+			return info, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", addr, err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, fmt.Errorf("metadata: %w", errors.Join(errs...))
+}
+
+// FetchFrom downloads the info dictionary of infoHash from the peer at
+// addr, a host:port.
+func (f *Fetcher) FetchFrom(ctx context.Context, addr string, infoHash magneturi.InfoHash) ([]byte, error) {
+	timeout := f.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	//unblock reads and writes when ctx is cancelled early
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	p := &peerConn{conn: conn}
+	if err := p.handshake(infoHash, f.peerID()); err != nil {
+		return nil, err
+	}
+	size, theirID, err := p.extendedHandshake(f.maxSize())
+	if err != nil {
+		return nil, err
+	}
+	info := make([]byte, 0, size)
+	for piece := 0; len(info) < size; piece++ {
+		data, err := p.piece(theirID, piece)
+		if err != nil {
+			return nil, err
+		}
+		want := size - len(info)
+		if want > PieceSize {
+			want = PieceSize
+		}
+		if len(data) != want {
+			return nil, fmt.Errorf("piece %d has %d bytes, want %d", piece, len(data), want)
+		}
+		info = append(info, data...)
+	}
+	if sha1.Sum(info) != infoHash {
+		return nil, fmt.Errorf("metadata does not match the info-hash %s", infoHash)
+	}
+	return info, nil
+}
+
+func (f *Fetcher) peerID() [20]byte {
+	if f.PeerID != ([20]byte{}) {
+		return f.PeerID
+	}
+	var id [20]byte
+	copy(id[:], "-MU0001-")
+	rand.Read(id[8:])
+	return id
+}
+
+func (f *Fetcher) maxSize() int {
+	if f.MaxSize > 0 {
+		return f.MaxSize
+	}
+	return DefaultMaxSize
+}
+
+type peerConn struct {
+	conn net.Conn
+}
+
+func (p *peerConn) handshake(infoHash magneturi.InfoHash, peerID [20]byte) error {
+	var reserved [8]byte
+	//BEP 10, the extension protocol bit
+	reserved[5] |= 0x10
+	msg := append([]byte(protocol), reserved[:]...)
+	msg = append(append(msg, infoHash[:]...), peerID[:]...)
+	if _, err := p.conn.Write(msg); err != nil {
+		return err
+	}
+	resp := make([]byte, 68)
+	if _, err := io.ReadFull(p.conn, resp); err != nil {
+		return err
+	}
+	if string(resp[:20]) != protocol {
+		return fmt.Errorf("not a BitTorrent peer")
+	}
+	if resp[25]&0x10 == 0 {
+		return fmt.Errorf("peer does not support the extension protocol")
+	}
+	if !bytes.Equal(resp[28:48], infoHash[:]) {
+		return fmt.Errorf("peer does not have the torrent")
+	}
+	return nil
+}
+
+// extendedHandshake returns the metadata size the peer announced and
+// the id it wants ut_metadata messages sent with.
+func (p *peerConn) extendedHandshake(maxSize int) (int, byte, error) {
+	hs, err := bencode.Encode(map[string]interface{}{
+		"m": map[string]interface{}{"ut_metadata": utMetadata},
+		"v": "magneturi",
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := p.writeExtended(extHandshake, hs); err != nil {
+		return 0, 0, err
+	}
+	for {
+		id, payload, err := p.readExtended()
+		if err != nil {
+			return 0, 0, err
+		}
+		if id != extHandshake {
+			continue
+		}
+		v, err := bencode.Decode(payload)
+		if err != nil {
+			return 0, 0, err
+		}
+		dict, _ := v.(map[string]interface{})
+		m, _ := dict["m"].(map[string]interface{})
+		theirID, _ := m["ut_metadata"].(int64)
+		if theirID <= 0 || theirID > 255 {
+			return 0, 0, fmt.Errorf("peer does not support ut_metadata")
+		}
+		size, _ := dict["metadata_size"].(int64)
+		if size <= 0 || size > int64(maxSize) {
+			return 0, 0, fmt.Errorf("invalid metadata size %d", size)
+		}
+		return int(size), byte(theirID), nil
+	}
+}
+
+func (p *peerConn) piece(theirID byte, piece int) ([]byte, error) {
+	req, err := bencode.Encode(map[string]interface{}{"msg_type": metadataRequest, "piece": piece})
+	if err != nil {
+		return nil, err
+	}
+	if err := p.writeExtended(theirID, req); err != nil {
+		return nil, err
+	}
+	for {
+		id, payload, err := p.readExtended()
+		if err != nil {
+			return nil, err
+		}
+		if id != utMetadata {
+			continue
+		}
+		v, n, err := bencode.DecodePrefix(payload)
+		if err != nil {
+			return nil, err
+		}
+		dict, _ := v.(map[string]interface{})
+		if got, _ := dict["piece"].(int64); got != int64(piece) {
+			continue
+		}
+		switch msgType, _ := dict["msg_type"].(int64); msgType {
+		case metadataData:
+			return payload[n:], nil
+		case metadataReject:
+			return nil, fmt.Errorf("peer rejected metadata piece %d", piece)
+		}
+	}
+}
+
+func (p *peerConn) writeExtended(id byte, payload []byte) error {
+	msg := binary.BigEndian.AppendUint32(nil, uint32(len(payload)+2))
+	msg = append(msg, msgExtended, id)
+	_, err := p.conn.Write(append(msg, payload...))
+	return err
+}
+
+// readExtended skips everything but extended messages.
+func (p *peerConn) readExtended() (byte, []byte, error) {
+	for {
+		var length [4]byte
+		if _, err := io.ReadFull(p.conn, length[:]); err != nil {
+			return 0, nil, err
+		}
+		n := binary.BigEndian.Uint32(length[:])
+		if n == 0 {
+			//keep-alive
+			continue
+		}
+		if n > maxMessage {
+			//a bitfield of a huge torrent can be this large, skip it
+			if _, err := io.CopyN(io.Discard, p.conn, int64(n)); err != nil {
+				return 0, nil, err
+			}
+			continue
+		}
+		msg := make([]byte, n)
+		if _, err := io.ReadFull(p.conn, msg); err != nil {
+			return 0, nil, err
+		}
+		if msg[0] == msgExtended && len(msg) >= 2 {
+			return msg[1], msg[2:], nil
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package metadata
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nmmh/magneturi/magneturi"
+	"github.com/nmmh/magneturi/magneturi/bencode"
+)
+
+// fakePeer serves the metadata of one torrent over the BitTorrent
+// wire protocol, misbehaving as told by mode.
+type fakePeer struct {
+	ln   net.Listener
+	info []byte
+	mode string
+}
+
+func newFakePeer(t *testing.T, info []byte, mode string) *fakePeer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &fakePeer{ln, info, mode}
+	go p.serve()
+	t.Cleanup(func() { ln.Close() })
+	return p
+}
+
+func (p *fakePeer) addr() string {
+	return p.ln.Addr().String()
+}
+
+func (p *fakePeer) serve() {
+	for {
+		conn, err := p.ln.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			conn.SetDeadline(time.Now().Add(5 * time.Second))
+			p.handle(conn)
+		}()
+	}
+}
+
+func (p *fakePeer) handle(conn net.Conn) {
+	hs := make([]byte, 68)
+	if _, err := io.ReadFull(conn, hs); err != nil {
+		return
+	}
+	if p.mode == "silent" {
+		io.Copy(io.Discard, conn)
+		return
+	}
+	var reserved [8]byte
+	if p.mode != "no extensions" {
+		reserved[5] = 0x10
+	}
+	infoHash := sha1.Sum(p.info)
+	resp := append([]byte(protocol), reserved[:]...)
+	resp = append(append(resp, infoHash[:]...), "-FP0001-000000000000"...)
+	conn.Write(resp)
+
+	//a bitfield and a keep-alive before the extended handshake
+	conn.Write([]byte{0, 0, 0, 2, 5, 0xff, 0, 0, 0, 0})
+	ext, _ := bencode.Encode(map[string]interface{}{
+		"m":             map[string]interface{}{"ut_metadata": 3},
+		"metadata_size": len(p.info),
+	})
+	writeMsg(conn, 0, ext)
+
+	var theirID byte
+	for {
+		var length [4]byte
+		if _, err := io.ReadFull(conn, length[:]); err != nil {
+			return
+		}
+		msg := make([]byte, binary.BigEndian.Uint32(length[:]))
+		if _, err := io.ReadFull(conn, msg); err != nil {
+			return
+		}
+		if msg[0] != msgExtended {
+			continue
+		}
+		v, _ := bencode.Decode(msg[2:])
+		dict, _ := v.(map[string]interface{})
+		if msg[1] == 0 {
+			m, _ := dict["m"].(map[string]interface{})
+			id, _ := m["ut_metadata"].(int64)
+			theirID = byte(id)
+			continue
+		}
+		if msg[1] != 3 {
+			continue
+		}
+		piece := int(dict["piece"].(int64))
+		if p.mode == "reject" {
+			reject, _ := bencode.Encode(map[string]interface{}{"msg_type": 2, "piece": piece})
+			writeMsg(conn, theirID, reject)
+			continue
+		}
+		start := piece * PieceSize
+		end := start + PieceSize
+		if end > len(p.info) {
+			end = len(p.info)
+		}
+		data := append([]byte{}, p.info[start:end]...)
+		if p.mode == "corrupt" {
+			data[0] ^= 0xff
+		}
+		header, _ := bencode.Encode(map[string]interface{}{"msg_type": 1, "piece": piece, "total_size": len(p.info)})
+		writeMsg(conn, theirID, append(header, data...))
+	}
+}
+
+func writeMsg(conn net.Conn, extID byte, payload []byte) {
+	msg := binary.BigEndian.AppendUint32(nil, uint32(len(payload)+2))
+	conn.Write(append(append(msg, msgExtended, extID), payload...))
+}
+
+func testInfo() ([]byte, magneturi.InfoHash) {
+	//three metadata pieces, the last one short
+	info, _ := bencode.Encode(map[string]interface{}{
+		"length":       int64(1) << 30,
+		"name":         "test.bin",
+		"piece length": 1 << 20,
+		"pieces":       strings.Repeat("0123456789abcdefghij", 2000),
+	})
+	return info, sha1.Sum(info)
+}
+
+func TestFetcher_Fetch(t *testing.T) {
+	info, infoHash := testInfo()
+	good := newFakePeer(t, info, "")
+	tests := []struct {
+		name    string
+		peers   []string
+		xpe     []string
+		wantErr bool
+	}{
+		{"supplied peer", []string{good.addr()}, nil, false},
+		{"x.pe peer", nil, []string{good.addr()}, false},
+		{"corrupt peer first", []string{newFakePeer(t, info, "corrupt").addr()}, []string{good.addr()}, false},
+		{"rejecting peer", []string{newFakePeer(t, info, "reject").addr()}, nil, true},
+		{"peer without extensions", []string{newFakePeer(t, info, "no extensions").addr()}, nil, true},
+		{"no peers", nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := "magnet:?xt=urn:btih:" + infoHash.String()
+			for _, pe := range tt.xpe {
+				raw += "&x.pe=" + url.QueryEscape(pe)
+			}
+			f := &Fetcher{Timeout: time.Second}
+			got, err := f.Fetch(context.Background(), magneturi.MustParse(raw), tt.peers...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Fetcher.Fetch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !bytes.Equal(got, info) {
+				t.Errorf("Fetcher.Fetch() returned %d bytes that differ from the info dictionary", len(got))
+			}
+		})
+	}
+}
+
+func TestFetcher_FetchFromTimeout(t *testing.T) {
+	info, infoHash := testInfo()
+	silent := newFakePeer(t, info, "silent")
+	f := &Fetcher{Timeout: 100 * time.Millisecond}
+	_, err := f.FetchFrom(context.Background(), silent.addr(), infoHash)
+	var ne net.Error
+	if !errors.As(err, &ne) || !ne.Timeout() {
+		t.Errorf("Fetcher.FetchFrom() error = %v, want a timeout", err)
+	}
+}
+
+func TestFetcher_FetchFromMaxSize(t *testing.T) {
+	info, infoHash := testInfo()
+	good := newFakePeer(t, info, "")
+	f := &Fetcher{Timeout: time.Second, MaxSize: 1024}
+	if _, err := f.FetchFrom(context.Background(), good.addr(), infoHash); err == nil {
+		t.Errorf("Fetcher.FetchFrom() of metadata above MaxSize, error = nil")
+	}
+}
@@ -0,0 +1,32 @@
+package magneturi
+
+import (
+	"fmt"
+	"net/url"
+	"unicode/utf8"
+)
+
+// DecodeName decodes a percent-encoded display name (dn) value. The
+// result must be valid UTF-8 and is returned in Unicode Normalization
+// Form C, so names that differ only in how accents are encoded, e.g.
+// "é" as one character or as "e" and a combining accent, are equal.
+func DecodeName(value string) (string, error) {
+	name, err := url.QueryUnescape(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid display name encoding: %q", value)
+	}
+	if !utf8.ValidString(name) {
+		return "", fmt.Errorf("display name is not valid UTF-8: %q", value)
+	}
+	return nfc(name), nil
+}
+
+// DisplayName returns the first display name of the link decoded by
+// DecodeName, or "" if it has none.
+func (m *MagnetURI) DisplayName() (string, error) {
+	value, ok := m.firstValue("dn")
+	if !ok {
+		return "", nil
+	}
+	return DecodeName(value)
+}
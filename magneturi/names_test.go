@@ -0,0 +1,38 @@
+package magneturi
+
+import "testing"
+
+func TestDecodeName(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"plain", "Example+File.iso", "Example File.iso", false},
+		{"composed", "Caf%C3%A9", "Café", false},
+		{"decomposed", "Cafe%CC%81", "Café", false},
+		{"invalid utf-8", "Caf%E9", "", true},
+		{"invalid encoding", "100%", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeName(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeName() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("DecodeName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMagnetURI_DisplayName(t *testing.T) {
+	if got, err := MustParse("magnet:?dn=Cafe%CC%81&dn=b").DisplayName(); err != nil || got != "Café" {
+		t.Errorf("DisplayName() = %q, %v", got, err)
+	}
+	if got, err := MustParse("magnet:?xt=urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a").DisplayName(); err != nil || got != "" {
+		t.Errorf("DisplayName() without dn = %q, %v", got, err)
+	}
+}
@@ -0,0 +1,141 @@
+package magneturi
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// NFC normalization (UAX #15) for display names: the same name typed
+// on macOS, which decomposes accents, and elsewhere compares equal
+// after it. The tables in nfc_tables.go are generated by gen_nfc.go.
+
+type nfcClassRange struct {
+	lo, hi rune
+	class  uint8
+}
+
+// Hangul syllables compose and decompose algorithmically.
+const (
+	hangulSBase  = 0xAC00
+	hangulLBase  = 0x1100
+	hangulVBase  = 0x1161
+	hangulTBase  = 0x11A7
+	hangulLCount = 19
+	hangulVCount = 21
+	hangulTCount = 28
+	hangulNCount = hangulVCount * hangulTCount
+	hangulSCount = hangulLCount * hangulNCount
+)
+
+// nfcCompositions are the primary composites by the pair they
+// decompose into: the two rune decompositions that are neither
+// excluded nor start with a non-starter.
+var nfcCompositions = func() map[[2]rune]rune {
+	excluded := map[rune]bool{}
+	for _, r := range nfcExclusions {
+		excluded[r] = true
+	}
+	compositions := map[[2]rune]rune{}
+	for r, d := range nfcDecompositions {
+		if d[1] == 0 || excluded[r] || combiningClass(r) != 0 || combiningClass(d[0]) != 0 {
+			continue
+		}
+		compositions[d] = r
+	}
+	return compositions
+}()
+
+func combiningClass(r rune) uint8 {
+	i := sort.Search(len(nfcClasses), func(i int) bool { return nfcClasses[i].hi >= r })
+	if i < len(nfcClasses) && nfcClasses[i].lo <= r {
+		return nfcClasses[i].class
+	}
+	return 0
+}
+
+func decompose(out []rune, r rune) []rune {
+	if s := r - hangulSBase; s >= 0 && s < hangulSCount {
+		out = append(out, hangulLBase+s/hangulNCount, hangulVBase+s%hangulNCount/hangulTCount)
+		if t := s % hangulTCount; t != 0 {
+			out = append(out, hangulTBase+t)
+		}
+		return out
+	}
+	if d, ok := nfcDecompositions[r]; ok {
+		out = decompose(out, d[0])
+		if d[1] != 0 {
+			out = decompose(out, d[1])
+		}
+		return out
+	}
+	return append(out, r)
+}
+
+func compose(a, b rune) (rune, bool) {
+	if l, v := a-hangulLBase, b-hangulVBase; l >= 0 && l < hangulLCount && v >= 0 && v < hangulVCount {
+		return hangulSBase + (l*hangulVCount+v)*hangulTCount, true
+	}
+	if s, t := a-hangulSBase, b-hangulTBase; s >= 0 && s < hangulSCount && s%hangulTCount == 0 && t > 0 && t < hangulTCount {
+		return a + t, true
+	}
+	r, ok := nfcCompositions[[2]rune{a, b}]
+	return r, ok
+}
+
+// nfc returns s in Normalization Form C. s must be valid UTF-8.
+func nfc(s string) string {
+	ascii := true
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return s
+	}
+
+	var runes []rune
+	for _, r := range s {
+		runes = decompose(runes, r)
+	}
+	// Canonical ordering: sort each run of non-starters by class,
+	// stable so that marks of the same class keep their order.
+	for i := 0; i < len(runes); {
+		if combiningClass(runes[i]) == 0 {
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && combiningClass(runes[j]) != 0 {
+			j++
+		}
+		marks := runes[i:j]
+		sort.SliceStable(marks, func(a, b int) bool {
+			return combiningClass(marks[a]) < combiningClass(marks[b])
+		})
+		i = j
+	}
+
+	// Canonical composition: a character combines with the last
+	// starter unless a character of the same or a higher class, or a
+	// starter, sits between them.
+	out := runes[:0]
+	starter := -1
+	var last int
+	for _, r := range runes {
+		class := int(combiningClass(r))
+		if starter >= 0 && (last < class || last == 0 && len(out)-1 == starter) {
+			if c, ok := compose(out[starter], r); ok {
+				out[starter] = c
+				continue
+			}
+		}
+		if class == 0 {
+			starter = len(out)
+		}
+		last = class
+		out = append(out, r)
+	}
+	return string(out)
+}
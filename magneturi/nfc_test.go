@@ -0,0 +1,32 @@
+package magneturi
+
+import "testing"
+
+func TestNFC(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"ascii", "Example File.iso", "Example File.iso"},
+		{"combining accent", "Café", "Café"},
+		{"already composed", "Café", "Café"},
+		{"singleton", "Å", "Å"},
+		{"reordered marks", "ḍ̇", "ḍ̇"},
+		{"blocked by same class", "á́", "á́"},
+		{"mark before starter", "́a", "́a"},
+		{"excluded composition", "क़", "क़"},
+		{"excluded character", "क़", "क़"},
+		{"hangul", "각", "각"},
+		{"hangul lv t", "각", "각"},
+		{"multiple levels", "Ǖ", "Ǖ"},
+		{"decomposed multiple levels", "Ǖ", "Ǖ"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nfc(tt.in); got != tt.want {
+				t.Errorf("nfc(%+q) = %+q, want %+q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package magneturi
+
+// Param is one parameter of a link. For tr.1=udp%3A%2F%2Fexample.org
+// the Prefix is "tr", the Index "1" and the Value is still encoded as
+// in the URI. Experimental parameters have the Prefix "x." and their
+// name as the Index.
+type Param struct {
+	Prefix string
+	Index  string
+	Value  string
+}
+
+// Key returns the name of the parameter as written in the URI, e.g.
+// "tr.1" or "x.pe".
+func (p Param) Key() string {
+	return paramKey(param{p.Prefix, p.Index, p.Value})
+}
+
+// Params returns the parameters of the link in order.
+func (m *MagnetURI) Params() []Param {
+	params := make([]Param, len(m.params))
+	for i, p := range m.params {
+		params[i] = Param{p.prefix, p.index, p.value}
+	}
+	return params
+}
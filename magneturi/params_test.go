@@ -0,0 +1,30 @@
+package magneturi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMagnetURI_Params(t *testing.T) {
+	m := MustParse("magnet:?xt.1=urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a&tr=udp%3A%2F%2Fa%3A80&x.pe=1.2.3.4:5")
+	want := []Param{
+		{"xt", "1", "urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a"},
+		{"tr", "", "udp%3A%2F%2Fa%3A80"},
+		{"x.", "pe", "1.2.3.4:5"},
+	}
+	got := m.Params()
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Params() = %v, want %v", got, want)
+	}
+	var keys []string
+	for _, p := range got {
+		keys = append(keys, p.Key())
+	}
+	if want := []string{"xt.1", "tr", "x.pe"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("Key() = %v, want %v", keys, want)
+	}
+	got[0].Value = "changed"
+	if m.Params()[0].Value == "changed" {
+		t.Errorf("Params() shares its parameters with the link")
+	}
+}
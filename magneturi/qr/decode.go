@@ -0,0 +1,247 @@
+package qr
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"math/bits"
+
+	"github.com/nmmh/magneturi/magneturi"
+)
+
+// ErrNotFound is returned when an image does not contain a readable
+// symbol.
+var ErrNotFound = errors.New("qr: no code found")
+
+// Decode reads the QR code in img and parses its text as a magnet
+// link.
+func Decode(img image.Image) (*magneturi.MagnetURI, error) {
+	text, err := DecodeText(img)
+	if err != nil {
+		return nil, err
+	}
+	return magneturi.Parse(text, false)
+}
+
+// DecodeText reads the text of the QR code in img.
+func DecodeText(img image.Image) (string, error) {
+	s, err := sample(img)
+	if err != nil {
+		return "", err
+	}
+	level, mask, err := s.readFormat()
+	if err != nil {
+		return "", err
+	}
+	s.applyMask(mask)
+	codewords := make([]byte, 0, rawCodewords(s.version))
+	i := 0
+	s.dataPositions(func(x, y int) {
+		if i%8 == 0 {
+			codewords = append(codewords, 0)
+		}
+		if s.dark[y][x] {
+			codewords[i/8] |= 0x80 >> (i % 8)
+		}
+		i++
+	})
+	data, err := deinterleave(codewords[:rawCodewords(s.version)], s.version, level)
+	if err != nil {
+		return "", err
+	}
+	return readSegments(data, s.version)
+}
+
+// sample finds the symbol by its dark bounding box and reads the
+// center of every module.
+func sample(img image.Image) (*symbol, error) {
+	b := img.Bounds()
+	dark := func(x, y int) bool {
+		return color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y < 128
+	}
+	minX, minY, maxX, maxY := b.Max.X, b.Max.Y, b.Min.X-1, b.Min.Y-1
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if dark(x, y) {
+				minX, minY = min(minX, x), min(minY, y)
+				maxX, maxY = max(maxX, x), max(maxY, y)
+			}
+		}
+	}
+	if maxX < minX {
+		return nil, ErrNotFound
+	}
+	// The top left module is the corner of a finder pattern, seven
+	// modules wide.
+	run := 0
+	for x := minX; x <= maxX && dark(x, minY); x++ {
+		run++
+	}
+	width, height := float64(maxX-minX+1), float64(maxY-minY+1)
+	module := float64(run) / 7
+	if module < 1 || math.Abs(width-height) > 2*module {
+		return nil, ErrNotFound
+	}
+	version := int(math.Round((width/module - 17) / 4))
+	if version < 1 || version > MaxVersion {
+		return nil, ErrNotFound
+	}
+	s := newSymbol(version)
+	pitchX, pitchY := width/float64(s.size), height/float64(s.size)
+	for y := 0; y < s.size; y++ {
+		for x := 0; x < s.size; x++ {
+			px := minX + int((float64(x)+0.5)*pitchX)
+			py := minY + int((float64(y)+0.5)*pitchY)
+			s.dark[y][x] = dark(px, py)
+		}
+	}
+	return s, nil
+}
+
+// readFormat returns the level and mask of the closest valid format
+// information in either copy.
+func (s *symbol) readFormat() (Level, int, error) {
+	var copies [2]int
+	for i := 0; i < 15; i++ {
+		for c, pos := range s.formatPositions(i) {
+			if s.dark[pos[1]][pos[0]] {
+				copies[c] |= 1 << i
+			}
+		}
+	}
+	bestLevel, bestMask, best := Auto, 0, 4
+	for _, level := range []Level{L, M, Q, H} {
+		for mask := 0; mask < 8; mask++ {
+			want := formatInfo(level, mask)
+			for _, got := range copies {
+				if d := bits.OnesCount(uint(want ^ got)); d < best {
+					bestLevel, bestMask, best = level, mask, d
+				}
+			}
+		}
+	}
+	if bestLevel == Auto {
+		return Auto, 0, fmt.Errorf("qr: unreadable format information")
+	}
+	return bestLevel, bestMask, nil
+}
+
+// deinterleave splits the codewords into blocks, corrects them and
+// returns the data codewords.
+func deinterleave(codewords []byte, version int, level Level) ([]byte, error) {
+	lengths, ecc := blockLengths(version, level)
+	blocks := make([][]byte, len(lengths))
+	i := 0
+	for j := 0; j < lengths[len(lengths)-1]; j++ {
+		for b, n := range lengths {
+			if j < n {
+				blocks[b] = append(blocks[b], codewords[i])
+				i++
+			}
+		}
+	}
+	for j := 0; j < ecc; j++ {
+		for b := range blocks {
+			blocks[b] = append(blocks[b], codewords[i])
+			i++
+		}
+	}
+	var data []byte
+	for b, block := range blocks {
+		if _, err := rsCorrect(block, ecc); err != nil {
+			return nil, fmt.Errorf("%w in block %d", err, b)
+		}
+		data = append(data, block[:lengths[b]]...)
+	}
+	return data, nil
+}
+
+const alphanumeric = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// readSegments decodes the numeric, alphanumeric and byte mode
+// segments of the data. Byte mode is read as UTF-8, ECI designators
+// are skipped.
+func readSegments(data []byte, version int) (string, error) {
+	r := bitReader{data: data}
+	class := 0
+	if version >= 27 {
+		class = 2
+	} else if version >= 10 {
+		class = 1
+	}
+	var text []byte
+	for r.left() >= 4 {
+		mode := r.read(4)
+		switch mode {
+		case 0:
+			return string(text), nil
+		case 1:
+			n := r.read([]int{10, 12, 14}[class])
+			for ; n >= 3; n -= 3 {
+				text = fmt.Appendf(text, "%03d", r.read(10))
+			}
+			if n == 2 {
+				text = fmt.Appendf(text, "%02d", r.read(7))
+			} else if n == 1 {
+				text = fmt.Appendf(text, "%d", r.read(4))
+			}
+		case 2:
+			n := r.read([]int{9, 11, 13}[class])
+			for ; n >= 2; n -= 2 {
+				v := r.read(11)
+				if v >= 45*45 {
+					return "", fmt.Errorf("qr: invalid alphanumeric data")
+				}
+				text = append(text, alphanumeric[v/45], alphanumeric[v%45])
+			}
+			if n == 1 {
+				v := r.read(6)
+				if v >= 45 {
+					return "", fmt.Errorf("qr: invalid alphanumeric data")
+				}
+				text = append(text, alphanumeric[v])
+			}
+		case 4:
+			n := r.read([]int{8, 16, 16}[class])
+			for ; n > 0; n-- {
+				text = append(text, byte(r.read(8)))
+			}
+		case 7:
+			r.read(8)
+		default:
+			return "", fmt.Errorf("qr: unsupported mode %d", mode)
+		}
+		if r.overrun {
+			return "", fmt.Errorf("qr: truncated data")
+		}
+	}
+	return string(text), nil
+}
+
+type bitReader struct {
+	data    []byte
+	n       int
+	overrun bool
+}
+
+func (r *bitReader) left() int {
+	return len(r.data)*8 - r.n
+}
+
+func (r *bitReader) read(width int) int {
+	v := 0
+	for ; width > 0; width-- {
+		v <<= 1
+		if r.n >= len(r.data)*8 {
+			r.overrun = true
+			continue
+		}
+		if r.data[r.n/8]&(0x80>>(r.n%8)) != 0 {
+			v |= 1
+		}
+		r.n++
+	}
+	return v
+}
@@ -0,0 +1,367 @@
+package qr
+
+import "fmt"
+
+// symbol is a QR code matrix while it is built or read.
+type symbol struct {
+	version int
+	size    int
+	dark    [][]bool
+	// function marks the modules of the finder, timing and alignment
+	// patterns and the format and version information.
+	function [][]bool
+}
+
+func newSymbol(version int) *symbol {
+	s := &symbol{version: version, size: size(version)}
+	s.dark = make([][]bool, s.size)
+	s.function = make([][]bool, s.size)
+	for y := range s.dark {
+		s.dark[y] = make([]bool, s.size)
+		s.function[y] = make([]bool, s.size)
+	}
+	s.drawFunctionPatterns()
+	return s
+}
+
+func (s *symbol) setFunction(x, y int, dark bool) {
+	s.dark[y][x] = dark
+	s.function[y][x] = true
+}
+
+func (s *symbol) drawFunctionPatterns() {
+	for i := 0; i < s.size; i++ {
+		s.setFunction(6, i, i%2 == 0)
+		s.setFunction(i, 6, i%2 == 0)
+	}
+	s.drawFinder(3, 3)
+	s.drawFinder(s.size-4, 3)
+	s.drawFinder(3, s.size-4)
+	positions := alignmentPositions(s.version)
+	last := len(positions) - 1
+	for i, y := range positions {
+		for j, x := range positions {
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue // the finder patterns are there
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					s.setFunction(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+	// Reserve the format information, drawFormat fills it in.
+	s.drawFormat(0)
+	s.drawVersion()
+}
+
+// drawFinder draws a finder pattern with its separator around the
+// center x, y.
+func (s *symbol) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx >= 0 && xx < s.size && yy >= 0 && yy < s.size {
+				d := max(abs(dx), abs(dy))
+				s.setFunction(xx, yy, d != 2 && d != 4)
+			}
+		}
+	}
+}
+
+// formatInfo returns the 15 bit format information of the level and
+// mask, with its BCH error correction.
+func formatInfo(level Level, mask int) int {
+	data := formatBits[level.index()]<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// formatPositions returns the two places of each of the 15 format
+// information bits, as x, y pairs.
+func (s *symbol) formatPositions(i int) [2][2]int {
+	var first [2]int
+	switch {
+	case i < 6:
+		first = [2]int{8, i}
+	case i < 8:
+		first = [2]int{8, i + 1}
+	case i == 8:
+		first = [2]int{7, 8}
+	default:
+		first = [2]int{14 - i, 8}
+	}
+	second := [2]int{s.size - 1 - i, 8}
+	if i >= 8 {
+		second = [2]int{8, s.size - 15 + i}
+	}
+	return [2][2]int{first, second}
+}
+
+func (s *symbol) drawFormat(bits int) {
+	for i := 0; i < 15; i++ {
+		for _, pos := range s.formatPositions(i) {
+			s.setFunction(pos[0], pos[1], bits>>i&1 != 0)
+		}
+	}
+	s.setFunction(8, s.size-8, true) // the dark module
+}
+
+// versionInfo returns the 18 bit version information, with its BCH
+// error correction.
+func versionInfo(version int) int {
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = rem<<1 ^ (rem>>11)*0x1f25
+	}
+	return version<<12 | rem
+}
+
+func (s *symbol) drawVersion() {
+	if s.version < 7 {
+		return
+	}
+	bits := versionInfo(s.version)
+	for i := 0; i < 18; i++ {
+		dark := bits>>i&1 != 0
+		a, b := s.size-11+i%3, i/3
+		s.setFunction(a, b, dark)
+		s.setFunction(b, a, dark)
+	}
+}
+
+// dataPositions calls f for the data modules in placement order,
+// upwards and downwards in columns two modules wide from the right.
+func (s *symbol) dataPositions(f func(x, y int)) {
+	for right := s.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // skip the vertical timing pattern
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < s.size; vert++ {
+			y := vert
+			if upward {
+				y = s.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				if x := right - j; !s.function[y][x] {
+					f(x, y)
+				}
+			}
+		}
+	}
+}
+
+func (s *symbol) drawCodewords(codewords []byte) {
+	i := 0
+	s.dataPositions(func(x, y int) {
+		if i < len(codewords)*8 {
+			s.dark[y][x] = codewords[i>>3]>>(7-i&7)&1 != 0
+		}
+		i++ // remainder bits stay light
+	})
+}
+
+func maskBit(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
+
+// applyMask flips the data modules the mask selects, applying it twice
+// undoes it.
+func (s *symbol) applyMask(mask int) {
+	for y := 0; y < s.size; y++ {
+		for x := 0; x < s.size; x++ {
+			if !s.function[y][x] && maskBit(mask, x, y) {
+				s.dark[y][x] = !s.dark[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores how hard the symbol is to read, masks are chosen to
+// keep it low.
+func (s *symbol) penalty() int {
+	p := 0
+	line := make([]bool, s.size)
+	for _, horizontal := range []bool{true, false} {
+		for i := 0; i < s.size; i++ {
+			for j := range line {
+				if horizontal {
+					line[j] = s.dark[i][j]
+				} else {
+					line[j] = s.dark[j][i]
+				}
+			}
+			p += linePenalty(line)
+		}
+	}
+	dark := 0
+	for y := 0; y < s.size; y++ {
+		for x := 0; x < s.size; x++ {
+			if s.dark[y][x] {
+				dark++
+			}
+			if x+1 < s.size && y+1 < s.size {
+				c := s.dark[y][x]
+				if c == s.dark[y][x+1] && c == s.dark[y+1][x] && c == s.dark[y+1][x+1] {
+					p += 3
+				}
+			}
+		}
+	}
+	total := s.size * s.size
+	p += (abs(dark*20-total*10)+total-1)/total*10 - 10
+	return p
+}
+
+// finderLike is the 1:1:3:1:1 finder pattern with light space on one
+// side, penalized in data areas.
+var finderLike = [][]bool{
+	{true, false, true, true, true, false, true, false, false, false, false},
+	{false, false, false, false, true, false, true, true, true, false, true},
+}
+
+func linePenalty(line []bool) int {
+	p := 0
+	run := 1
+	for i := 1; i <= len(line); i++ {
+		if i < len(line) && line[i] == line[i-1] {
+			run++
+			continue
+		}
+		if run >= 5 {
+			p += run - 2
+		}
+		run = 1
+	}
+	for i := 0; i+11 <= len(line); i++ {
+		for _, pattern := range finderLike {
+			match := true
+			for j, dark := range pattern {
+				if line[i+j] != dark {
+					match = false
+					break
+				}
+			}
+			if match {
+				p += 40
+			}
+		}
+	}
+	return p
+}
+
+// encodeBytes returns the codewords of text in byte mode for the
+// version and level, data and error correction interleaved.
+func encodeBytes(text string, version int, level Level) ([]byte, error) {
+	capacity := dataCodewords(version, level)
+	var bits bitWriter
+	bits.write(0x4, 4)
+	bits.write(len(text), countBits(version))
+	for i := 0; i < len(text); i++ {
+		bits.write(int(text[i]), 8)
+	}
+	if bits.n > capacity*8 {
+		return nil, fmt.Errorf("qr: %d bytes do not fit version %d-%v", len(text), version, level)
+	}
+	bits.write(0, min(4, capacity*8-bits.n)) // terminator
+	bits.write(0, (8-bits.n%8)%8)
+	for pad := 0xec; bits.n < capacity*8; pad ^= 0xec ^ 0x11 {
+		bits.write(pad, 8)
+	}
+	return interleave(bits.bytes, version, level), nil
+}
+
+// countBits is the width of the byte mode character count.
+func countBits(version int) int {
+	if version < 10 {
+		return 8
+	}
+	return 16
+}
+
+// blockLengths returns the data length of each block and the number
+// of error correction codewords per block.
+func blockLengths(version int, level Level) ([]int, int) {
+	l := level.index()
+	blocks, ecc := numBlocks[l][version], eccPerBlock[l][version]
+	raw := rawCodewords(version)
+	short := raw/blocks - ecc
+	lengths := make([]int, blocks)
+	for i := range lengths {
+		lengths[i] = short
+		if i >= blocks-raw%blocks {
+			lengths[i]++
+		}
+	}
+	return lengths, ecc
+}
+
+func interleave(data []byte, version int, level Level) []byte {
+	lengths, ecc := blockLengths(version, level)
+	blocks := make([][]byte, len(lengths))
+	eccs := make([][]byte, len(lengths))
+	for i, n := range lengths {
+		blocks[i], data = data[:n], data[n:]
+		eccs[i] = rsEncode(blocks[i], ecc)
+	}
+	var out []byte
+	for i := 0; i < lengths[len(lengths)-1]; i++ {
+		for _, b := range blocks {
+			if i < len(b) {
+				out = append(out, b[i])
+			}
+		}
+	}
+	for i := 0; i < ecc; i++ {
+		for _, e := range eccs {
+			out = append(out, e[i])
+		}
+	}
+	return out
+}
+
+type bitWriter struct {
+	bytes []byte
+	n     int
+}
+
+func (w *bitWriter) write(v, bits int) {
+	for i := bits - 1; i >= 0; i-- {
+		if w.n%8 == 0 {
+			w.bytes = append(w.bytes, 0)
+		}
+		if v>>i&1 != 0 {
+			w.bytes[w.n/8] |= 0x80 >> (w.n % 8)
+		}
+		w.n++
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
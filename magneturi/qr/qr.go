@@ -0,0 +1,191 @@
+// Package qr renders magnet links as QR codes (ISO/IEC 18004) to PNG,
+// SVG and ANSI terminal output, and reads them back from images.
+//
+// Links are encoded in byte mode. The error correction level is chosen
+// by length unless set: the smallest symbol that holds the link at level
+// L is found, then the highest level that still fits it is used, so
+// short links get more redundancy for free. Encode can drop trackers to
+// fit a link in a small symbol, the info-hash is all a client needs.
+//
+// The decoder reads clean, axis aligned images such as the ones this
+// package renders or screenshots of them, not camera photos.
+package qr
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nmmh/magneturi/magneturi"
+)
+
+// Level is the error correction level.
+type Level int
+
+// Error correction levels, the share of codewords that can be restored
+// is roughly 7% for L, 15% for M, 25% for Q and 30% for H.
+const (
+	Auto Level = iota
+	L
+	M
+	Q
+	H
+)
+
+// index is the level's row in the capacity tables.
+func (l Level) index() int {
+	return int(l) - 1
+}
+
+// String returns the level's letter, or "auto".
+func (l Level) String() string {
+	switch l {
+	case L, M, Q, H:
+		return "LMQH"[l.index() : l.index()+1]
+	case Auto:
+		return "auto"
+	}
+	return fmt.Sprintf("Level(%d)", int(l))
+}
+
+// MaxVersion is the largest symbol version, 177x177 modules.
+const MaxVersion = 40
+
+// Options control the encoding of a link.
+type Options struct {
+	// Level is the error correction level, Auto chooses it by length.
+	Level Level
+	// MaxVersion limits the symbol size to 17+4*MaxVersion modules, 0
+	// means 40. Version 10 (57x57) still scans well from a phone screen.
+	MaxVersion int
+	// StripTrackers drops trackers from the end of the link until it
+	// fits MaxVersion, instead of failing.
+	StripTrackers bool
+}
+
+func (o Options) maxVersion() int {
+	if o.MaxVersion <= 0 || o.MaxVersion > MaxVersion {
+		return MaxVersion
+	}
+	return o.MaxVersion
+}
+
+// Code is an encoded QR code symbol.
+type Code struct {
+	// Version is the symbol version, 1 to 40.
+	Version int
+	// Level is the error correction level used.
+	Level Level
+	// Mask is the data mask pattern, 0 to 7.
+	Mask int
+	// Text is the encoded text, the link as it will be read back.
+	Text string
+	// StrippedTrackers is the number of trackers dropped to fit.
+	StrippedTrackers int
+
+	modules [][]bool
+}
+
+// Size returns the width of the symbol in modules, without the quiet
+// zone.
+func (c *Code) Size() int {
+	return len(c.modules)
+}
+
+// Dark reports whether the module at column x and row y is dark.
+// Modules outside of the symbol are light.
+func (c *Code) Dark(x, y int) bool {
+	return y >= 0 && y < len(c.modules) && x >= 0 && x < len(c.modules) && c.modules[y][x]
+}
+
+// Encode encodes the link.
+func Encode(m *magneturi.MagnetURI, opts Options) (*Code, error) {
+	params := m.Params()
+	var trackers []int
+	for i, p := range params {
+		if p.Prefix == "tr" {
+			trackers = append(trackers, i)
+		}
+	}
+	for stripped := 0; ; stripped++ {
+		drop := map[int]bool{}
+		for _, i := range trackers[len(trackers)-stripped:] {
+			drop[i] = true
+		}
+		code, err := EncodeText(linkText(params, drop), opts.Level, opts.maxVersion())
+		if err == nil {
+			code.StrippedTrackers = stripped
+			return code, nil
+		}
+		if !opts.StripTrackers || stripped == len(trackers) {
+			return nil, err
+		}
+	}
+}
+
+// linkText assembles the link from its parameters as String does,
+// without the dropped ones.
+func linkText(params []magneturi.Param, drop map[int]bool) string {
+	var keys []string
+	for i, p := range params {
+		if !drop[i] {
+			keys = append(keys, p.Key()+"="+p.Value)
+		}
+	}
+	return "magnet:?" + strings.Join(keys, "&")
+}
+
+// EncodeText encodes text in the smallest symbol up to maxVersion.
+func EncodeText(text string, level Level, maxVersion int) (*Code, error) {
+	if level < Auto || level > H {
+		return nil, fmt.Errorf("qr: invalid level %d", int(level))
+	}
+	if maxVersion <= 0 || maxVersion > MaxVersion {
+		maxVersion = MaxVersion
+	}
+	search := level
+	if level == Auto {
+		search = L
+	}
+	version := 0
+	for v := 1; v <= maxVersion; v++ {
+		if fits(len(text), v, search) {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, fmt.Errorf("qr: %d bytes do not fit version %d-%v", len(text), maxVersion, search)
+	}
+	if level == Auto {
+		level = L
+		for _, l := range []Level{H, Q, M} {
+			if fits(len(text), version, l) {
+				level = l
+				break
+			}
+		}
+	}
+	codewords, err := encodeBytes(text, version, level)
+	if err != nil {
+		return nil, err
+	}
+	s := newSymbol(version)
+	s.drawCodewords(codewords)
+	mask, best := 0, -1
+	for i := 0; i < 8; i++ {
+		s.applyMask(i)
+		s.drawFormat(formatInfo(level, i))
+		if p := s.penalty(); best < 0 || p < best {
+			mask, best = i, p
+		}
+		s.applyMask(i)
+	}
+	s.applyMask(mask)
+	s.drawFormat(formatInfo(level, mask))
+	return &Code{Version: version, Level: level, Mask: mask, Text: text, modules: s.dark}, nil
+}
+
+// fits reports whether n bytes fit the version at the level.
+func fits(n, version int, level Level) bool {
+	return 4+countBits(version)+8*n <= 8*dataCodewords(version, level) && n < 1<<countBits(version)
+}
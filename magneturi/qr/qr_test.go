@@ -0,0 +1,197 @@
+package qr
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/nmmh/magneturi/magneturi"
+)
+
+const link = "magnet:?xt=urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a&dn=Example+File.iso" +
+	"&tr=udp%3A%2F%2Ftracker.example.org%3A6969%2Fannounce" +
+	"&tr=udp%3A%2F%2Ftracker.example.net%3A1337%2Fannounce" +
+	"&tr=https%3A%2F%2Ftracker.example.com%2Fannounce"
+
+func TestFormatInfo(t *testing.T) {
+	if got, want := formatInfo(L, 0), 0x77c4; got != want {
+		t.Errorf("formatInfo(L, 0) = %015b, want %015b", got, want)
+	}
+	if got, want := formatInfo(M, 5), 0x40ce; got != want {
+		t.Errorf("formatInfo(M, 5) = %015b, want %015b", got, want)
+	}
+	if got, want := versionInfo(7), 0x07c94; got != want {
+		t.Errorf("versionInfo(7) = %018b, want %018b", got, want)
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	tests := []struct {
+		version int
+		level   Level
+		want    int
+	}{
+		{1, L, 17},
+		{1, H, 7},
+		{10, M, 213},
+		{40, L, 2953},
+		{40, H, 1273},
+	}
+	for _, tt := range tests {
+		n := 0
+		for fits(n+1, tt.version, tt.level) {
+			n++
+		}
+		if n != tt.want {
+			t.Errorf("capacity of %d-%v = %d, want %d", tt.version, tt.level, n, tt.want)
+		}
+	}
+}
+
+func TestEncodeText(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		level       Level
+		maxVersion  int
+		wantVersion int
+		wantLevel   Level
+		wantErr     bool
+	}{
+		{"auto raises the level", "magnet:?", Auto, 0, 1, Q, false},
+		{"auto", strings.Repeat("a", 80), Auto, 0, 5, M, false},
+		{"fixed level", strings.Repeat("a", 100), H, 0, 10, H, false},
+		{"two byte count", strings.Repeat("a", 300), L, 0, 11, L, false},
+		{"too long", strings.Repeat("a", 100), L, 4, 0, 0, true},
+		{"invalid level", "a", Level(9), 0, 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := EncodeText(tt.text, tt.level, tt.maxVersion)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EncodeText() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if code.Version != tt.wantVersion || code.Level != tt.wantLevel {
+				t.Errorf("EncodeText() = %d-%v, want %d-%v", code.Version, code.Level, tt.wantVersion, tt.wantLevel)
+			}
+			got, err := DecodeText(code.Image(3))
+			if err != nil || got != tt.text {
+				t.Errorf("DecodeText() = %q, %v, want %q", got, err, tt.text)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	m := magneturi.MustParse(link)
+	for _, level := range []Level{Auto, L, M, Q, H} {
+		for _, scale := range []int{1, 4} {
+			code, err := Encode(m, Options{Level: level})
+			if err != nil {
+				t.Fatalf("Encode(%v) error = %v", level, err)
+			}
+			var buf bytes.Buffer
+			if err := code.WritePNG(&buf, scale); err != nil {
+				t.Fatal(err)
+			}
+			img, err := png.Decode(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Decode(img)
+			if err != nil {
+				t.Fatalf("Decode(%v, scale %d) error = %v", level, scale, err)
+			}
+			if !got.Equal(*m) {
+				t.Errorf("Decode(%v, scale %d) = %s, want %s", level, scale, got, m)
+			}
+		}
+	}
+}
+
+func TestDecodeDamaged(t *testing.T) {
+	code, err := EncodeText(link, H, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Flip a 5x5 patch of modules in the data area.
+	for y := 20; y < 25; y++ {
+		for x := 20; x < 25; x++ {
+			code.modules[y][x] = !code.modules[y][x]
+		}
+	}
+	if got, err := DecodeText(code.Image(2)); err != nil || got != link {
+		t.Errorf("DecodeText() = %q, %v, want %q", got, err, link)
+	}
+}
+
+func TestStripTrackers(t *testing.T) {
+	m := magneturi.MustParse(link)
+	tests := []struct {
+		name         string
+		opts         Options
+		wantStripped int
+		wantErr      bool
+	}{
+		{"fits", Options{MaxVersion: 10}, 0, false},
+		{"does not fit", Options{Level: H, MaxVersion: 11}, 0, true},
+		{"stripped", Options{Level: H, MaxVersion: 11, StripTrackers: true}, 2, false},
+		{"all stripped", Options{Level: H, MaxVersion: 8, StripTrackers: true}, 3, false},
+		{"never fits", Options{Level: H, MaxVersion: 7, StripTrackers: true}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := Encode(m, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Encode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if code.StrippedTrackers != tt.wantStripped {
+				t.Errorf("Encode() stripped %d trackers, want %d", code.StrippedTrackers, tt.wantStripped)
+			}
+			got, err := Decode(code.Image(2))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n := len(got.Trackers()); n != 3-tt.wantStripped {
+				t.Errorf("Decode() has %d trackers, want %d", n, 3-tt.wantStripped)
+			}
+		})
+	}
+}
+
+func TestWriteSVG(t *testing.T) {
+	code, err := EncodeText("magnet:?", Auto, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := code.WriteSVG(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.String(); !strings.Contains(s, `viewBox="0 0 29 29"`) || !strings.Contains(s, "M4,4h1v1h-1z") {
+		t.Errorf("WriteSVG() = %s", s)
+	}
+}
+
+func TestWriteTerminal(t *testing.T) {
+	code, err := EncodeText("magnet:?", Auto, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := code.WriteTerminal(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	finder := strings.Repeat("\x1b[47m  ", 4) + strings.Repeat("\x1b[40m  ", 7) + "\x1b[47m  "
+	if len(lines) != 29 || !strings.HasPrefix(lines[4], finder) || !strings.HasSuffix(lines[4], "\x1b[0m") {
+		t.Errorf("WriteTerminal() = %d lines, row 4 = %q", len(lines), lines[4])
+	}
+}
@@ -0,0 +1,195 @@
+package qr
+
+import "errors"
+
+// GF(256) with the QR code polynomial x^8 + x^4 + x^3 + x^2 + 1.
+var gfExp, gfLog = func() ([512]byte, [256]byte) {
+	var exp [512]byte
+	var log [256]byte
+	x := 1
+	for i := 0; i < 255; i++ {
+		exp[i] = byte(x)
+		log[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11d
+		}
+	}
+	for i := 255; i < 512; i++ {
+		exp[i] = exp[i-255]
+	}
+	return exp, log
+}()
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+255-int(gfLog[b])]
+}
+
+// gfPow returns 2^n.
+func gfPow(n int) byte {
+	n %= 255
+	if n < 0 {
+		n += 255
+	}
+	return gfExp[n]
+}
+
+// polyEval evaluates p, highest degree first, at x.
+func polyEval(p []byte, x byte) byte {
+	var y byte
+	for _, c := range p {
+		y = gfMul(y, x) ^ c
+	}
+	return y
+}
+
+// rsGenerator returns the generator polynomial of degree n, without
+// its leading 1, highest degree first.
+func rsGenerator(n int) []byte {
+	g := make([]byte, n)
+	g[n-1] = 1
+	root := byte(1)
+	for i := 0; i < n; i++ {
+		// multiply by (x - root)
+		for j := 0; j < n; j++ {
+			g[j] = gfMul(g[j], root)
+			if j+1 < n {
+				g[j] ^= g[j+1]
+			}
+		}
+		root = gfMul(root, 2)
+	}
+	return g
+}
+
+// rsEncode returns the n error correction codewords of data.
+func rsEncode(data []byte, n int) []byte {
+	g := rsGenerator(n)
+	ecc := make([]byte, n)
+	for _, b := range data {
+		factor := b ^ ecc[0]
+		copy(ecc, ecc[1:])
+		ecc[n-1] = 0
+		for i := range ecc {
+			ecc[i] ^= gfMul(g[i], factor)
+		}
+	}
+	return ecc
+}
+
+var errTooManyErrors = errors.New("qr: too many errors to correct")
+
+// rsCorrect corrects the codeword block in place, the last n bytes of
+// which are error correction codewords, and returns the number of
+// bytes it corrected.
+func rsCorrect(block []byte, n int) (int, error) {
+	syndromes := make([]byte, n)
+	clean := true
+	for i := range syndromes {
+		syndromes[i] = polyEval(block, gfPow(i))
+		clean = clean && syndromes[i] == 0
+	}
+	if clean {
+		return 0, nil
+	}
+
+	// Berlekamp-Massey, the polynomials are lowest degree first.
+	locator := []byte{1}
+	prev := []byte{1}
+	l, m, b := 0, 1, byte(1)
+	for i := 0; i < n; i++ {
+		d := syndromes[i]
+		for j := 1; j <= l && j < len(locator); j++ {
+			d ^= gfMul(locator[j], syndromes[i-j])
+		}
+		if d == 0 {
+			m++
+			continue
+		}
+		next := append([]byte{}, locator...)
+		scale := gfDiv(d, b)
+		for len(next) < len(prev)+m {
+			next = append(next, 0)
+		}
+		for j, c := range prev {
+			next[j+m] ^= gfMul(scale, c)
+		}
+		if 2*l <= i {
+			l, prev, b, m = i+1-l, locator, d, 1
+		} else {
+			m++
+		}
+		locator = next
+	}
+	if 2*l > n {
+		return 0, errTooManyErrors
+	}
+
+	// Chien search for the error positions, the byte at index k has
+	// the degree len(block)-1-k.
+	var positions []int
+	for k := range block {
+		xInv := gfPow(-(len(block) - 1 - k))
+		var y byte
+		for j := len(locator) - 1; j >= 0; j-- {
+			y = gfMul(y, xInv) ^ locator[j]
+		}
+		if y == 0 {
+			positions = append(positions, k)
+		}
+	}
+	if len(positions) != l {
+		return 0, errTooManyErrors
+	}
+
+	// Forney, with the evaluator omega = syndromes * locator mod x^n.
+	omega := make([]byte, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j <= i && j < len(locator); j++ {
+			omega[i] ^= gfMul(locator[j], syndromes[i-j])
+		}
+	}
+	for _, k := range positions {
+		x := gfPow(len(block) - 1 - k)
+		xInv := gfDiv(1, x)
+		var num, den byte
+		for i := n - 1; i >= 0; i-- {
+			num = gfMul(num, xInv) ^ omega[i]
+		}
+		// the formal derivative keeps the odd powers
+		for j := len(locator) - 1; j >= 1; j-- {
+			if j%2 == 1 {
+				den ^= gfMul(locator[j], gfPowByte(xInv, j-1))
+			}
+		}
+		if den == 0 {
+			return 0, errTooManyErrors
+		}
+		block[k] ^= gfMul(x, gfDiv(num, den))
+	}
+	for i := 0; i < n; i++ {
+		if polyEval(block, gfPow(i)) != 0 {
+			return 0, errTooManyErrors
+		}
+	}
+	return len(positions), nil
+}
+
+// gfPowByte returns x^n.
+func gfPowByte(x byte, n int) byte {
+	y := byte(1)
+	for i := 0; i < n; i++ {
+		y = gfMul(y, x)
+	}
+	return y
+}
@@ -0,0 +1,47 @@
+package qr
+
+import (
+	"bytes"
+	"testing"
+)
+
+// helloWorld is the data of "HELLO WORLD" at 1-M, from the ISO/IEC
+// 18004 example.
+var helloWorld = []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+
+func TestRSEncode(t *testing.T) {
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := rsEncode(helloWorld, 10); !bytes.Equal(got, want) {
+		t.Errorf("rsEncode() = %v, want %v", got, want)
+	}
+}
+
+func TestRSCorrect(t *testing.T) {
+	tests := []struct {
+		name    string
+		errors  []int
+		wantErr bool
+	}{
+		{name: "clean"},
+		{name: "one", errors: []int{3}},
+		{name: "data and ecc", errors: []int{0, 20}},
+		{name: "capacity", errors: []int{1, 5, 9, 15, 25}},
+		{name: "too many", errors: []int{1, 5, 9, 15, 21, 25}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := append(append([]byte{}, helloWorld...), rsEncode(helloWorld, 10)...)
+			block := append([]byte{}, want...)
+			for i, pos := range tt.errors {
+				block[pos] ^= byte(0x5a + i)
+			}
+			n, err := rsCorrect(block, 10)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("rsCorrect() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (n != len(tt.errors) || !bytes.Equal(block, want)) {
+				t.Errorf("rsCorrect() = %d, %v, want %d, %v", n, block, len(tt.errors), want)
+			}
+		})
+	}
+}
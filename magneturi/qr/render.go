@@ -0,0 +1,83 @@
+package qr
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+)
+
+// QuietZone is the light border around the symbol in modules, as the
+// standard requires.
+const QuietZone = 4
+
+// Image returns the code as a black and white image with scale pixels
+// per module, quiet zone included.
+func (c *Code) Image(scale int) image.Image {
+	if scale < 1 {
+		scale = 1
+	}
+	width := (c.Size() + 2*QuietZone) * scale
+	img := image.NewPaletted(image.Rect(0, 0, width, width), color.Palette{color.White, color.Black})
+	for y := 0; y < width; y++ {
+		for x := 0; x < width; x++ {
+			if c.Dark(x/scale-QuietZone, y/scale-QuietZone) {
+				img.Pix[y*img.Stride+x] = 1
+			}
+		}
+	}
+	return img
+}
+
+// WritePNG writes the code as a PNG image with scale pixels per module.
+func (c *Code) WritePNG(w io.Writer, scale int) error {
+	return png.Encode(w, c.Image(scale))
+}
+
+// WriteSVG writes the code as an SVG image, one unit per module. The
+// dark modules are a single path, so it scales without seams.
+func (c *Code) WriteSVG(w io.Writer) error {
+	width := c.Size() + 2*QuietZone
+	var path strings.Builder
+	for y := 0; y < c.Size(); y++ {
+		for x := 0; x < c.Size(); x++ {
+			if c.Dark(x, y) {
+				fmt.Fprintf(&path, "M%d,%dh1v1h-1z", x+QuietZone, y+QuietZone)
+			}
+		}
+	}
+	_, err := fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" version="1.1" viewBox="0 0 %d %d" shape-rendering="crispEdges">
+<rect width="100%%" height="100%%" fill="#ffffff"/>
+<path d="%s" fill="#000000"/>
+</svg>
+`, width, width, path.String())
+	return err
+}
+
+// WriteTerminal writes the code with ANSI background colors, two
+// characters per module so it stays square in most fonts. Light
+// modules are white rather than the terminal's background, for dark
+// themes.
+func (c *Code) WriteTerminal(w io.Writer) error {
+	const (
+		dark  = "\x1b[40m  "
+		light = "\x1b[47m  "
+		reset = "\x1b[0m"
+	)
+	bw := bufio.NewWriter(w)
+	for y := -QuietZone; y < c.Size()+QuietZone; y++ {
+		for x := -QuietZone; x < c.Size()+QuietZone; x++ {
+			if c.Dark(x, y) {
+				bw.WriteString(dark)
+			} else {
+				bw.WriteString(light)
+			}
+		}
+		bw.WriteString(reset + "\n")
+	}
+	return bw.Flush()
+}
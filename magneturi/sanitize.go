@@ -0,0 +1,271 @@
+package magneturi
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Policy selects what Sanitize scrubs from a link.
+type Policy struct {
+	// DropExperimental drops every experimental (x.) parameter.
+	DropExperimental bool
+	// StripPasskeys removes the passkey, as found by ParseTracker,
+	// from tracker URLs.
+	StripPasskeys bool
+	// DropPrivateSources drops exact (xs) and acceptable (as) sources
+	// on localhost or a loopback, private, carrier-grade NAT or
+	// link-local IP address, also when it is written in a shorthand
+	// such as 127.1 or 2130706433.
+	DropPrivateSources bool
+	// DropNonHTTPSources drops exact and acceptable sources that are
+	// not http or https URLs.
+	DropNonHTTPSources bool
+	// CleanNames removes control characters and bidirectional text
+	// controls, which can disguise a file extension, from display
+	// names (dn).
+	CleanNames bool
+	// MaxNameLength cuts display names to that many characters, 0
+	// means no limit.
+	MaxNameLength int
+}
+
+// StrictPolicy applies every rule and keeps names to 255 characters,
+// the file name limit of most file systems.
+var StrictPolicy = Policy{
+	DropExperimental:   true,
+	StripPasskeys:      true,
+	DropPrivateSources: true,
+	DropNonHTTPSources: true,
+	CleanNames:         true,
+	MaxNameLength:      255,
+}
+
+// Rule names the Policy rule behind a Change.
+type Rule string
+
+// The rules of a Policy.
+const (
+	RuleExperimental   Rule = "experimental parameter"
+	RulePasskey        Rule = "tracker passkey"
+	RulePrivateSource  Rule = "private source address"
+	RuleNonHTTPSource  Rule = "source is not http(s)"
+	RuleNameCharacters Rule = "control characters in name"
+	RuleNameLength     Rule = "name too long"
+)
+
+// Change is an edit Sanitize made to a parameter.
+type Change struct {
+	Rule Rule
+	// Param is the parameter before the change.
+	Param Param
+	// Dropped reports whether the parameter was removed, otherwise
+	// Value is its new value.
+	Dropped bool
+	Value   string
+}
+
+func (c Change) String() string {
+	if c.Dropped {
+		return "dropped " + c.Param.Key() + "=" + c.Param.Value + ": " + string(c.Rule)
+	}
+	return "changed " + c.Param.Key() + "=" + c.Param.Value + " to " + c.Value + ": " + string(c.Rule)
+}
+
+// Sanitize returns a copy of m scrubbed according to the policy, for
+// links submitted by untrusted users, and the changes it made in link
+// order. m is not modified.
+func Sanitize(m *MagnetURI, policy Policy) (*MagnetURI, []Change) {
+	s := &MagnetURI{}
+	var changes []Change
+	for _, p := range m.params {
+		original := Param{p.prefix, p.index, p.value}
+		change := func(rule Rule, value string) {
+			changes = append(changes, Change{Rule: rule, Param: original, Dropped: value == "", Value: value})
+			original.Value = value
+			p.value = value
+		}
+		switch p.prefix {
+		case "x.":
+			if policy.DropExperimental {
+				change(RuleExperimental, "")
+			}
+		case "tr":
+			if policy.StripPasskeys {
+				if value, ok := stripPasskey(p.value); ok {
+					change(RulePasskey, value)
+				}
+			}
+		case "xs", "as":
+			u := sourceURL(p.value)
+			isHTTP := u != nil && (u.Scheme == "http" || u.Scheme == "https")
+			if policy.DropNonHTTPSources && !isHTTP {
+				change(RuleNonHTTPSource, "")
+			} else if policy.DropPrivateSources && u != nil && isPrivateHost(u.Hostname()) {
+				change(RulePrivateSource, "")
+			}
+		case "dn":
+			name, err := url.QueryUnescape(p.value)
+			if err != nil {
+				name = p.value
+			}
+			if policy.CleanNames {
+				if cleaned := cleanName(name); cleaned != name {
+					name = cleaned
+					change(RuleNameCharacters, url.QueryEscape(name))
+				}
+			}
+			if n := policy.MaxNameLength; n > 0 && p.value != "" && utf8.RuneCountInString(name) > n {
+				name = string([]rune(name)[:n])
+				change(RuleNameLength, url.QueryEscape(name))
+			}
+		}
+		if p.value != "" {
+			s.params = append(s.params, p)
+		}
+	}
+	return s, changes
+}
+
+// stripPasskey returns the tracker value without its passkey, encoded
+// the way it was.
+func stripPasskey(value string) (string, bool) {
+	t, err := ParseTracker(value)
+	if err != nil || t.Passkey == "" {
+		return "", false
+	}
+	u := *t.URL
+	var kept []string
+	for _, kv := range strings.Split(u.RawQuery, "&") {
+		name, _, _ := strings.Cut(kv, "=")
+		if name, err := url.QueryUnescape(name); err == nil && isPasskeyParam(name) {
+			continue
+		}
+		if kv != "" {
+			kept = append(kept, kv)
+		}
+	}
+	u.RawQuery = strings.Join(kept, "&")
+	if match := passkeyPath.FindStringSubmatch(u.Path); match != nil && match[1] == t.Passkey {
+		u.Path = strings.TrimPrefix(u.Path, "/"+t.Passkey)
+		u.RawPath = ""
+	}
+	if !strings.Contains(value, "://") {
+		return url.QueryEscape(u.String()), true
+	}
+	return u.String(), true
+}
+
+func isPasskeyParam(name string) bool {
+	for _, k := range passkeyParams {
+		if name == k {
+			return true
+		}
+	}
+	return false
+}
+
+// privateNetworks are the ranges isPrivateHost adds to those of the
+// net.IP methods: "this network" and carrier-grade NAT.
+var privateNetworks = []*net.IPNet{
+	{IP: net.IPv4(0, 0, 0, 0), Mask: net.CIDRMask(8, 32)},
+	{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)},
+}
+
+func isPrivateHost(host string) bool {
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		var ok bool
+		if ip, ok = parseIPv4Shorthand(host); !ok {
+			// A host ending in a number that is no address is not a
+			// name either, resolvers disagree on what it means.
+			return true
+		}
+		if ip == nil {
+			return false
+		}
+	}
+	for _, n := range privateNetworks {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsUnspecified()
+}
+
+// parseIPv4Shorthand parses the IPv4 forms URL parsers accept besides
+// dotted decimal, as defined by the WHATWG URL standard: one to four
+// parts in decimal, octal with a leading 0 or hex with 0x, the last
+// part filling the remaining bytes, e.g. "127.1", "2130706433" and
+// "0x7f.0.0.1" are all 127.0.0.1. It returns a nil IP for a host name,
+// and false for a host that ends in a number but is no address.
+func parseIPv4Shorthand(host string) (net.IP, bool) {
+	parts := strings.Split(strings.TrimSuffix(host, "."), ".")
+	if _, ok := parseIPv4Part(parts[len(parts)-1]); !ok {
+		if last := parts[len(parts)-1]; last == "" || strings.Trim(last, "0123456789") != "" {
+			return nil, true
+		}
+	}
+	if len(parts) > 4 {
+		return nil, false
+	}
+	var n uint64
+	for i, part := range parts {
+		v, ok := parseIPv4Part(part)
+		if !ok {
+			return nil, false
+		}
+		if i < len(parts)-1 {
+			if v > 255 {
+				return nil, false
+			}
+			n = n<<8 | v
+			continue
+		}
+		rest := uint(4 - i)
+		if v >= 1<<(8*rest) {
+			return nil, false
+		}
+		n = n<<(8*rest) | v
+	}
+	return net.IPv4(byte(n>>24), byte(n>>16), byte(n>>8), byte(n)), true
+}
+
+func parseIPv4Part(part string) (uint64, bool) {
+	base := 10
+	switch {
+	case len(part) >= 2 && (part[:2] == "0x" || part[:2] == "0X"):
+		part, base = part[2:], 16
+		if part == "" {
+			return 0, true
+		}
+	case len(part) > 1 && part[0] == '0':
+		part, base = part[1:], 8
+	}
+	v, err := strconv.ParseUint(part, base, 32)
+	return v, err == nil
+}
+
+// bidiControls are the Unicode bidirectional formatting characters,
+// e.g. U+202E RIGHT-TO-LEFT OVERRIDE before "gpj.exe" displays it as
+// "exe.jpg".
+var bidiControls = map[rune]bool{
+	'\u061c': true, '\u200e': true, '\u200f': true,
+	'\u202a': true, '\u202b': true, '\u202c': true, '\u202d': true, '\u202e': true,
+	'\u2066': true, '\u2067': true, '\u2068': true, '\u2069': true,
+}
+
+func cleanName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || bidiControls[r] {
+			return -1
+		}
+		return r
+	}, name)
+}
@@ -0,0 +1,171 @@
+package magneturi
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name        string
+		link        string
+		policy      Policy
+		want        string
+		wantChanges []Change
+	}{
+		{
+			name:   "empty policy",
+			link:   "magnet:?dn=a%E2%80%AE&x.pe=1.2.3.4:5&xs=dchub://example.org",
+			policy: Policy{},
+			want:   "magnet:?dn=a%E2%80%AE&x.pe=1.2.3.4:5&xs=dchub://example.org",
+		},
+		{
+			name:   "experimental",
+			link:   "magnet:?xt=urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a&x.pe=1.2.3.4:5",
+			policy: Policy{DropExperimental: true},
+			want:   "magnet:?xt=urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a",
+			wantChanges: []Change{
+				{Rule: RuleExperimental, Param: Param{"x.", "pe", "1.2.3.4:5"}, Dropped: true},
+			},
+		},
+		{
+			name: "passkeys",
+			link: "magnet:?tr=http%3A%2F%2Ft.example.org%2Fannounce.php%3Fpasskey%3D0123456789abcdef%26info%3D1" +
+				"&tr.1=https://t.example.org/0123456789abcdef0123456789abcdef/announce" +
+				"&tr=udp%3A%2F%2Fpublic.example.org%3A80%2Fannounce",
+			policy: Policy{StripPasskeys: true},
+			want: "magnet:?tr=http%3A%2F%2Ft.example.org%2Fannounce.php%3Finfo%3D1&tr.1=https://t.example.org/announce" +
+				"&tr=udp%3A%2F%2Fpublic.example.org%3A80%2Fannounce",
+			wantChanges: []Change{
+				{
+					Rule:  RulePasskey,
+					Param: Param{"tr", "", "http%3A%2F%2Ft.example.org%2Fannounce.php%3Fpasskey%3D0123456789abcdef%26info%3D1"},
+					Value: "http%3A%2F%2Ft.example.org%2Fannounce.php%3Finfo%3D1",
+				},
+				{
+					Rule:  RulePasskey,
+					Param: Param{"tr", "1", "https://t.example.org/0123456789abcdef0123456789abcdef/announce"},
+					Value: "https://t.example.org/announce",
+				},
+			},
+		},
+		{
+			name: "sources",
+			link: "magnet:?xs=http%3A%2F%2F192.168.1.10%2Ff&as=https://cache.example.org/f&xs=dchub://example.org" +
+				"&as=http://localhost:8080/f&xs=urn:btpk:abc&as=http%3A%2F%2F%5Bfe80%3A%3A1%5D%2Ff",
+			policy: Policy{DropPrivateSources: true, DropNonHTTPSources: true},
+			want:   "magnet:?as=https://cache.example.org/f",
+			wantChanges: []Change{
+				{Rule: RulePrivateSource, Param: Param{"xs", "", "http%3A%2F%2F192.168.1.10%2Ff"}, Dropped: true},
+				{Rule: RuleNonHTTPSource, Param: Param{"xs", "", "dchub://example.org"}, Dropped: true},
+				{Rule: RulePrivateSource, Param: Param{"as", "", "http://localhost:8080/f"}, Dropped: true},
+				{Rule: RuleNonHTTPSource, Param: Param{"xs", "", "urn:btpk:abc"}, Dropped: true},
+				{Rule: RulePrivateSource, Param: Param{"as", "", "http%3A%2F%2F%5Bfe80%3A%3A1%5D%2Ff"}, Dropped: true},
+			},
+		},
+		{
+			name:   "private sources only",
+			link:   "magnet:?xs=dchub://example.org&xs=http://10.0.0.1/f",
+			policy: Policy{DropPrivateSources: true},
+			want:   "magnet:?xs=dchub://example.org",
+			wantChanges: []Change{
+				{Rule: RulePrivateSource, Param: Param{"xs", "", "http://10.0.0.1/f"}, Dropped: true},
+			},
+		},
+		{
+			name:   "names",
+			link:   "magnet:?dn=photo%E2%80%AEgpj.exe%0A&dn.2=abcdefgh&dn.3=%07",
+			policy: Policy{CleanNames: true, MaxNameLength: 6},
+			want:   "magnet:?dn=photog&dn.2=abcdef",
+			wantChanges: []Change{
+				{Rule: RuleNameCharacters, Param: Param{"dn", "", "photo%E2%80%AEgpj.exe%0A"}, Value: "photogpj.exe"},
+				{Rule: RuleNameLength, Param: Param{"dn", "", "photogpj.exe"}, Value: "photog"},
+				{Rule: RuleNameLength, Param: Param{"dn", "2", "abcdefgh"}, Value: "abcdef"},
+				{Rule: RuleNameCharacters, Param: Param{"dn", "3", "%07"}, Dropped: true},
+			},
+		},
+		{
+			name:   "length counts characters",
+			link:   "magnet:?dn=%C3%A9t%C3%A9+2",
+			policy: Policy{MaxNameLength: 4},
+			want:   "magnet:?dn=%C3%A9t%C3%A9+",
+			wantChanges: []Change{
+				{Rule: RuleNameLength, Param: Param{"dn", "", "%C3%A9t%C3%A9+2"}, Value: "%C3%A9t%C3%A9+"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := MustParse(tt.link)
+			got, changes := Sanitize(m, tt.policy)
+			if got.String() != tt.want {
+				t.Errorf("Sanitize() = %s, want %s", got, tt.want)
+			}
+			if !reflect.DeepEqual(changes, tt.wantChanges) {
+				t.Errorf("Sanitize() changes = %v, want %v", changes, tt.wantChanges)
+			}
+			if m.String() != tt.link {
+				t.Errorf("Sanitize() modified the link: %s", m)
+			}
+		})
+	}
+}
+
+func TestSanitizeStrict(t *testing.T) {
+	m := MustParse("magnet:?xt=urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a&dn=" + strings.Repeat("a", 300) + "&x.pe=1.2.3.4:5")
+	got, changes := Sanitize(m, StrictPolicy)
+	if want := "magnet:?xt=urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a&dn=" + strings.Repeat("a", 255); got.String() != want || len(changes) != 2 {
+		t.Errorf("Sanitize() = %s with %v", got, changes)
+	}
+	if s := changes[1].String(); s != "dropped x.pe=1.2.3.4:5: experimental parameter" {
+		t.Errorf("Change.String() = %q", s)
+	}
+}
+
+func TestIsPrivateHost(t *testing.T) {
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"example.org", false},
+		{"93.184.216.34", false},
+		{"localhost", true},
+		{"a.localhost", true},
+		{"127.0.0.1", true},
+		{"::1", true},
+		{"10.1.2.3", true},
+		{"100.64.0.1", true},
+		{"100.127.255.255", true},
+		{"100.128.0.1", false},
+		{"0.1.2.3", true},
+		{"2130706433", true},
+		{"127.1", true},
+		{"0x7f.0.0.1", true},
+		{"0x7F000001", true},
+		{"0177.0.0.1", true},
+		{"10.0x10203", true},
+		{"1572395042", false},
+		{"0x5d.0xb8.0xd8.0x22", false},
+		{"127.0.0.1.", true},
+		{"0x", true},
+		{"089", true},
+		{"4294967296", true},
+		{"1.2.3.4.5", true},
+		{"1.2.300.4", true},
+		{"example.0x7g", false},
+	}
+	for _, tt := range tests {
+		if got := isPrivateHost(tt.host); got != tt.want {
+			t.Errorf("isPrivateHost(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeShorthandAddresses(t *testing.T) {
+	m := MustParse("magnet:?xs=http://2130706433/f&xs=http://127.1/f&as=http://0x7f.0.0.1/f&as=http://100.64.1.1/f&xs=http://example.org/f")
+	got, changes := Sanitize(m, Policy{DropPrivateSources: true})
+	if want := "magnet:?xs=http://example.org/f"; got.String() != want || len(changes) != 4 {
+		t.Errorf("Sanitize() = %s with %v, want %s", got, changes, want)
+	}
+}
@@ -0,0 +1,215 @@
+// Package scrape asks trackers for the swarm statistics of the
+// info-hashes of a magnet link, over HTTP (/scrape) or the BEP 15 UDP
+// tracker protocol.
+package scrape
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nmmh/magneturi/magneturi"
+	"github.com/nmmh/magneturi/magneturi/bencode"
+	"github.com/nmmh/magneturi/magneturi/internal/udptracker"
+)
+
+// ErrUnsupported is returned for HTTP trackers whose announce URL does
+// not follow the convention scrape URLs are derived from.
+var ErrUnsupported = errors.New("scrape: tracker does not support scraping")
+
+// Result is the swarm statistics of one info-hash.
+type Result struct {
+	InfoHash  magneturi.InfoHash
+	Seeders   int
+	Leechers  int
+	Completed int
+}
+
+// TrackerResult is the outcome of scraping one tracker.
+type TrackerResult struct {
+	Tracker magneturi.Tracker
+	Results []Result
+	Err     error
+}
+
+// Client scrapes trackers, the zero value is ready to use.
+type Client struct {
+	// HTTPClient is used for HTTP trackers, http.DefaultClient if nil.
+	HTTPClient *http.Client
+	// Retries is how often a failed request is repeated.
+	Retries int
+	// Timeout is how long the first attempt at a UDP request waits
+	// for an answer, it doubles with every retry. Defaults to
+	// udptracker.DefaultTimeout.
+	Timeout time.Duration
+}
+
+// maxUDPHashes is the most info-hashes a UDP scrape packet can hold.
+const maxUDPHashes = 74
+
+// ScrapeMagnet scrapes the info-hashes of m on each of its trackers
+// concurrently. Results are in the order of m.Trackers.
+func (c *Client) ScrapeMagnet(ctx context.Context, m *magneturi.MagnetURI) ([]TrackerResult, error) {
+	hashes := m.InfoHashes()
+	if len(hashes) == 0 {
+		return nil, fmt.Errorf("scrape: magnet uri has no btih exact topic")
+	}
+	trackers := m.Trackers()
+	results := make([]TrackerResult, len(trackers))
+	var wg sync.WaitGroup
+	for i, t := range trackers {
+		wg.Add(1)
+		go func(i int, t magneturi.Tracker) {
+			defer wg.Done()
+			r, err := c.scrape(ctx, t, hashes)
+			results[i] = TrackerResult{t, r, err}
+		}(i, t)
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// Scrape asks the tracker with the given announce URL for the
+// statistics of the info-hashes.
+func (c *Client) Scrape(ctx context.Context, announceURL string, hashes ...magneturi.InfoHash) ([]Result, error) {
+	t, err := magneturi.ParseTracker(announceURL)
+	if err != nil {
+		return nil, err
+	}
+	return c.scrape(ctx, t, hashes)
+}
+
+func (c *Client) scrape(ctx context.Context, t magneturi.Tracker, hashes []magneturi.InfoHash) ([]Result, error) {
+	switch t.Scheme {
+	case "udp":
+		return c.scrapeUDP(ctx, t, hashes)
+	case "http", "https":
+		return c.scrapeHTTP(ctx, t, hashes)
+	}
+	return nil, ErrUnsupported
+}
+
+// URL derives the scrape URL from an announce URL as described in
+// BEP 48, the last path segment has to start with "announce".
+func URL(announce *url.URL) (*url.URL, error) {
+	i := strings.LastIndex(announce.Path, "/")
+	if i < 0 || !strings.HasPrefix(announce.Path[i+1:], "announce") {
+		return nil, ErrUnsupported
+	}
+	u := *announce
+	u.Path = announce.Path[:i+1] + "scrape" + strings.TrimPrefix(announce.Path[i+1:], "announce")
+	u.RawPath = ""
+	return &u, nil
+}
+
+func (c *Client) scrapeHTTP(ctx context.Context, t magneturi.Tracker, hashes []magneturi.InfoHash) ([]Result, error) {
+	u, err := URL(t.URL)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	for _, h := range hashes {
+		q.Add("info_hash", string(h[:]))
+	}
+	u.RawQuery = q.Encode()
+	var body []byte
+	err = udptracker.Retry(ctx, c.Retries, func(attempt int) error {
+		body, err = c.get(ctx, u.String())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	v, err := bencode.Decode(body)
+	if err != nil {
+		return nil, fmt.Errorf("scrape: invalid response from %s: %v", t.Host, err)
+	}
+	dict, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("scrape: invalid response from %s: not a dictionary", t.Host)
+	}
+	if reason, ok := dict["failure reason"].(string); ok {
+		return nil, fmt.Errorf("scrape: %s: %s", t.Host, reason)
+	}
+	files, _ := dict["files"].(map[string]interface{})
+	results := make([]Result, 0, len(hashes))
+	for _, h := range hashes {
+		stats, ok := files[string(h[:])].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		results = append(results, Result{
+			InfoHash:  h,
+			Seeders:   intValue(stats["complete"]),
+			Leechers:  intValue(stats["incomplete"]),
+			Completed: intValue(stats["downloaded"]),
+		})
+	}
+	return results, nil
+}
+
+func intValue(v interface{}) int {
+	n, _ := v.(int64)
+	return int(n)
+}
+
+func (c *Client) get(ctx context.Context, u string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("scrape: %s: %s", req.URL.Host, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+func (c *Client) scrapeUDP(ctx context.Context, t magneturi.Tracker, hashes []magneturi.InfoHash) ([]Result, error) {
+	conn, err := udptracker.Dial(ctx, t.Host, t.Port, c.Retries, c.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("scrape: %s: %w", t.Host, err)
+	}
+	defer conn.Close()
+	var results []Result
+	for len(hashes) > 0 {
+		batch := hashes
+		if len(batch) > maxUDPHashes {
+			batch = batch[:maxUDPHashes]
+		}
+		hashes = hashes[len(batch):]
+		var body []byte
+		for _, h := range batch {
+			body = append(body, h[:]...)
+		}
+		resp, err := conn.Do(ctx, udptracker.ActionScrape, body, 8+12*len(batch))
+		if err != nil {
+			return nil, fmt.Errorf("scrape: %s: %w", t.Host, err)
+		}
+		for i, h := range batch {
+			stats := resp[8+12*i:]
+			results = append(results, Result{
+				InfoHash:  h,
+				Seeders:   int(binary.BigEndian.Uint32(stats[0:4])),
+				Completed: int(binary.BigEndian.Uint32(stats[4:8])),
+				Leechers:  int(binary.BigEndian.Uint32(stats[8:12])),
+			})
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,132 @@
+package scrape
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nmmh/magneturi/magneturi"
+	"github.com/nmmh/magneturi/magneturi/internal/trackertest"
+)
+
+var (
+	hash1, _ = magneturi.ParseInfoHash("c12fe1c06bba254a9dc9f519b335aa7c1367a88a")
+	hash2, _ = magneturi.ParseInfoHash("QHQXPYWMACKDWKP47RRVIV7VOURXFE5Q")
+)
+
+func newTracker() *trackertest.Tracker {
+	tr := trackertest.New()
+	tr.SetSwarm(hash1, trackertest.Swarm{Seeders: 5, Leechers: 10, Completed: 50})
+	tr.SetSwarm(hash2, trackertest.Swarm{Seeders: 1, Leechers: 2, Completed: 3})
+	return tr
+}
+
+func TestClient_Scrape(t *testing.T) {
+	tr := newTracker()
+	defer tr.Close()
+	want := []Result{
+		{hash1, 5, 10, 50},
+		{hash2, 1, 2, 3},
+	}
+	for _, announce := range []string{tr.UDPAnnounceURL(), tr.HTTPAnnounceURL()} {
+		t.Run(announce, func(t *testing.T) {
+			c := &Client{}
+			got, err := c.Scrape(context.Background(), announce, hash1, hash2)
+			if err != nil {
+				t.Fatalf("Client.Scrape() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Client.Scrape() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestClient_ScrapeUDPRetry(t *testing.T) {
+	tr := newTracker()
+	defer tr.Close()
+	tr.DropUDP(2)
+	c := &Client{Retries: 2, Timeout: 50 * time.Millisecond}
+	got, err := c.Scrape(context.Background(), tr.UDPAnnounceURL(), hash1)
+	if err != nil {
+		t.Fatalf("Client.Scrape() error = %v", err)
+	}
+	if want := []Result{{hash1, 5, 10, 50}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Client.Scrape() = %v, want %v", got, want)
+	}
+}
+
+func TestClient_ScrapeUDPDeadline(t *testing.T) {
+	tr := newTracker()
+	defer tr.Close()
+	tr.DropUDP(100)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	c := &Client{Retries: 10, Timeout: time.Second}
+	start := time.Now()
+	if _, err := c.Scrape(ctx, tr.UDPAnnounceURL(), hash1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Client.Scrape() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Client.Scrape() took %v, the context deadline was ignored", elapsed)
+	}
+}
+
+func TestClient_ScrapeMagnet(t *testing.T) {
+	tr := newTracker()
+	defer tr.Close()
+	m := magneturi.MustParse("magnet:?xt=urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a" +
+		"&tr=" + url.QueryEscape(tr.UDPAnnounceURL()) +
+		"&tr=" + url.QueryEscape(tr.HTTPAnnounceURL()) +
+		"&tr=http://127.0.0.1:1/tracker.php")
+	results, err := (&Client{}).ScrapeMagnet(context.Background(), m)
+	if err != nil {
+		t.Fatalf("Client.ScrapeMagnet() error = %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Client.ScrapeMagnet() returned %d results, want 3", len(results))
+	}
+	want := []Result{{hash1, 5, 10, 50}}
+	for _, r := range results[:2] {
+		if r.Err != nil || !reflect.DeepEqual(r.Results, want) {
+			t.Errorf("Client.ScrapeMagnet() %s = %v, %v, want %v", r.Tracker, r.Results, r.Err, want)
+		}
+	}
+	if results[2].Err != ErrUnsupported {
+		t.Errorf("Client.ScrapeMagnet() %s error = %v, want %v", results[2].Tracker, results[2].Err, ErrUnsupported)
+	}
+
+	if _, err := (&Client{}).ScrapeMagnet(context.Background(), magneturi.MustParse("magnet:?dn=x")); err == nil {
+		t.Errorf("Client.ScrapeMagnet() without btih, error = nil")
+	}
+}
+
+func TestURL(t *testing.T) {
+	tests := []struct {
+		announce string
+		want     string
+		wantErr  bool
+	}{
+		{"http://example.com/announce", "http://example.com/scrape", false},
+		{"http://example.com/x/announce", "http://example.com/x/scrape", false},
+		{"http://example.com/announce.php", "http://example.com/scrape.php", false},
+		{"http://example.com/announce?x2%0644", "http://example.com/scrape?x2%0644", false},
+		{"http://example.com/announce?passkey=abc", "http://example.com/scrape?passkey=abc", false},
+		{"http://example.com/a", "", true},
+		{"http://example.com/announce/x", "", true},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.announce)
+		got, err := URL(u)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("URL(%q) error = %v, wantErr %v", tt.announce, err, tt.wantErr)
+			continue
+		}
+		if err == nil && got.String() != tt.want {
+			t.Errorf("URL(%q) = %v, want %v", tt.announce, got, tt.want)
+		}
+	}
+}
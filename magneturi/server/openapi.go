@@ -0,0 +1,118 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// OpenAPI returns the OpenAPI 3 spec of the endpoints as JSON. It is
+// generated from the request and response types, so it cannot drift
+// from what the server does.
+func OpenAPI() ([]byte, error) {
+	return json.MarshalIndent(spec(), "", "  ")
+}
+
+// commonErrors are the error statuses of every endpoint.
+var commonErrors = []int{
+	http.StatusBadRequest,
+	http.StatusMethodNotAllowed,
+	http.StatusRequestEntityTooLarge,
+}
+
+func spec() map[string]interface{} {
+	schemas := map[string]interface{}{}
+	paths := map[string]interface{}{}
+	errorRef := schemaRef(reflect.TypeOf(ErrorResponse{}), schemas)
+	for _, rt := range routes {
+		responses := map[string]interface{}{
+			"200": map[string]interface{}{
+				"description": "OK",
+				"content":     jsonContent(schemaRef(reflect.TypeOf(rt.response), schemas)),
+			},
+		}
+		for _, status := range append(append([]int{}, commonErrors...), rt.errors...) {
+			responses[strconv.Itoa(status)] = map[string]interface{}{
+				"description": http.StatusText(status),
+				"content":     jsonContent(errorRef),
+			}
+		}
+		content := jsonContent(schemaRef(reflect.TypeOf(rt.request), schemas))
+		if rt.path == "/from-torrent" {
+			content["application/x-bittorrent"] = map[string]interface{}{
+				"schema": map[string]interface{}{"type": "string", "format": "binary"},
+			}
+		}
+		paths[rt.path] = map[string]interface{}{
+			"post": map[string]interface{}{
+				"summary":     rt.summary,
+				"operationId": strings.TrimPrefix(rt.path, "/"),
+				"requestBody": map[string]interface{}{"required": true, "content": content},
+				"responses":   responses,
+			},
+		}
+	}
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "magneturi",
+			"version": "1",
+		},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": schemas},
+	}
+}
+
+func jsonContent(schema interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"application/json": map[string]interface{}{"schema": schema},
+	}
+}
+
+// schemaRef returns the schema of t, named struct types are added to
+// schemas and referenced.
+func schemaRef(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": schemaRef(t.Elem(), schemas)}
+	case reflect.Struct:
+		if _, ok := schemas[t.Name()]; !ok {
+			schemas[t.Name()] = nil // guards against recursion
+			schemas[t.Name()] = structSchema(t, schemas)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	}
+	panic("server: no schema for " + t.String())
+}
+
+func structSchema(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	properties := map[string]interface{}{}
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		properties[name] = schemaRef(f.Type, schemas)
+		if opts != "omitempty" {
+			required = append(required, name)
+		}
+	}
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
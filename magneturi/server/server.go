@@ -0,0 +1,424 @@
+// Package server serves the magneturi library as JSON over HTTP, for
+// services that are not written in Go. Every endpoint takes a POST
+// with a JSON body, errors are answered with an ErrorResponse.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+
+	"github.com/nmmh/magneturi/magneturi"
+)
+
+// DefaultMaxBodySize is the default limit of a request body in bytes.
+const DefaultMaxBodySize = 1 << 20
+
+// Options configure the handler returned by New.
+type Options struct {
+	// MaxBodySize limits request bodies in bytes, DefaultMaxBodySize
+	// if zero.
+	MaxBodySize int64
+}
+
+// URIRequest names a link, Soft parses it as magneturi.Parse does
+// with softParse, dropping invalid parameters instead of failing.
+type URIRequest struct {
+	URI  string `json:"uri"`
+	Soft bool   `json:"soft,omitempty"`
+}
+
+// ValidateRequest asks to validate a link against the named profiles,
+// the generic profile if there are none.
+type ValidateRequest struct {
+	URI      string   `json:"uri"`
+	Soft     bool     `json:"soft,omitempty"`
+	Profiles []string `json:"profiles,omitempty"`
+}
+
+// MergeRequest names links for the same content to combine.
+type MergeRequest struct {
+	URIs []string `json:"uris"`
+	Soft bool     `json:"soft,omitempty"`
+}
+
+// FromTorrentRequest carries a .torrent file, base64 encoded in JSON.
+// The endpoint also takes the file itself as application/x-bittorrent.
+type FromTorrentRequest struct {
+	Torrent []byte `json:"torrent"`
+}
+
+// URIResponse is a link.
+type URIResponse struct {
+	URI string `json:"uri"`
+}
+
+// ValidateResponse lists the violations of the first failing profile.
+type ValidateResponse struct {
+	Valid      bool     `json:"valid"`
+	Profile    string   `json:"profile,omitempty"`
+	Violations []string `json:"violations,omitempty"`
+}
+
+// Link is a parsed link.
+type Link struct {
+	URI         string       `json:"uri"`
+	Description string       `json:"description"`
+	Params      []Param      `json:"params"`
+	ExactTopics []ExactTopic `json:"exactTopics"`
+	InfoHashes  []string     `json:"infoHashes"`
+	Trackers    []Tracker    `json:"trackers"`
+}
+
+// Param is a parameter of a link, Value as in the URI and Decoded
+// with the percent-encoding removed.
+type Param struct {
+	Key     string `json:"key"`
+	Prefix  string `json:"prefix"`
+	Index   string `json:"index,omitempty"`
+	Value   string `json:"value"`
+	Decoded string `json:"decoded"`
+}
+
+// ExactTopic is a parsed exact topic (xt).
+type ExactTopic struct {
+	Index     string `json:"index,omitempty"`
+	Namespace string `json:"namespace"`
+	Hash      string `json:"hash"`
+	Valid     bool   `json:"valid"`
+}
+
+// Tracker is a parsed tracker (tr).
+type Tracker struct {
+	Index  string `json:"index,omitempty"`
+	URL    string `json:"url"`
+	Scheme string `json:"scheme"`
+	Host   string `json:"host"`
+	Port   int    `json:"port,omitempty"`
+	// Private is a loopback or private range IP address.
+	Private bool `json:"private"`
+	LAN     bool `json:"lan"`
+	Onion   bool `json:"onion"`
+}
+
+// ErrorResponse is the body of every error answer.
+type ErrorResponse struct {
+	Error Error `json:"error"`
+}
+
+// Error describes what went wrong. Code is one of invalid_request,
+// request_too_large, unsupported_media_type, invalid_magnet_uri,
+// unknown_profile, conflict, invalid_torrent, method_not_allowed,
+// not_found and internal. For invalid_magnet_uri Kind is the magneturi.ParseErrorKind
+// and Input the rejected part of the URI.
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Kind    string `json:"kind,omitempty"`
+	Input   string `json:"input,omitempty"`
+}
+
+// apiError is an Error with the HTTP status it is sent with.
+type apiError struct {
+	status int
+	body   Error
+}
+
+func (e *apiError) Error() string {
+	return e.body.Message
+}
+
+func errorf(status int, code, format string, args ...interface{}) *apiError {
+	return &apiError{status, Error{Code: code, Message: fmt.Sprintf(format, args...)}}
+}
+
+// parseErrorStatus maps the kinds of parse errors to HTTP statuses: a
+// URI that is not a magnet link at all is a bad request, a magnet link
+// with broken parameters cannot be processed.
+var parseErrorStatus = map[magneturi.ParseErrorKind]int{
+	magneturi.KindScheme: http.StatusBadRequest,
+	magneturi.KindSyntax: http.StatusUnprocessableEntity,
+	magneturi.KindPrefix: http.StatusUnprocessableEntity,
+	magneturi.KindIndex:  http.StatusUnprocessableEntity,
+}
+
+type route struct {
+	path     string
+	summary  string
+	request  interface{}
+	response interface{}
+	// errors are the error statuses the endpoint answers with besides
+	// the ones every endpoint can send.
+	errors []int
+	handle func(s *server, r *http.Request) (interface{}, error)
+}
+
+var routes = []route{
+	{
+		path:     "/parse",
+		summary:  "Parse a magnet link into its parameters, exact topics and trackers.",
+		request:  URIRequest{},
+		response: Link{},
+		errors:   []int{http.StatusUnprocessableEntity},
+		handle:   (*server).parse,
+	},
+	{
+		path:     "/validate",
+		summary:  "Validate a magnet link against validation profiles (generic, BitTorrent, eD2k, Gnutella).",
+		request:  ValidateRequest{},
+		response: ValidateResponse{},
+		errors:   []int{http.StatusUnprocessableEntity},
+		handle:   (*server).validate,
+	},
+	{
+		path:     "/canonicalize",
+		summary:  "Return the canonical form of a magnet link.",
+		request:  URIRequest{},
+		response: URIResponse{},
+		errors:   []int{http.StatusUnprocessableEntity},
+		handle:   (*server).canonicalize,
+	},
+	{
+		path:     "/merge",
+		summary:  "Merge magnet links for the same content into one canonical link.",
+		request:  MergeRequest{},
+		response: URIResponse{},
+		errors:   []int{http.StatusConflict, http.StatusUnprocessableEntity},
+		handle:   (*server).merge,
+	},
+	{
+		path:     "/from-torrent",
+		summary:  "Build a magnet link from a .torrent file.",
+		request:  FromTorrentRequest{},
+		response: Link{},
+		errors:   []int{http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity},
+		handle:   (*server).fromTorrent,
+	},
+}
+
+type server struct {
+	maxBodySize int64
+}
+
+// New returns the handler serving the endpoints, and the OpenAPI spec
+// of them at GET /openapi.json.
+func New(opts Options) http.Handler {
+	s := &server{maxBodySize: opts.MaxBodySize}
+	if s.maxBodySize <= 0 {
+		s.maxBodySize = DefaultMaxBodySize
+	}
+	mux := http.NewServeMux()
+	for _, rt := range routes {
+		rt := rt
+		mux.HandleFunc(rt.path, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				w.Header().Set("Allow", http.MethodPost)
+				writeError(w, errorf(http.StatusMethodNotAllowed, "method_not_allowed", "%s needs a POST", rt.path))
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, s.maxBodySize)
+			resp, err := rt.handle(s, r)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, resp)
+		})
+	}
+	mux.HandleFunc("/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			writeError(w, errorf(http.StatusMethodNotAllowed, "method_not_allowed", "/openapi.json needs a GET"))
+			return
+		}
+		writeJSON(w, http.StatusOK, spec())
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, errorf(http.StatusNotFound, "not_found", "no endpoint %s", r.URL.Path))
+	})
+	return mux
+}
+
+func (s *server) parse(r *http.Request) (interface{}, error) {
+	var req URIRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	m, err := parseURI(req.URI, req.Soft)
+	if err != nil {
+		return nil, err
+	}
+	return newLink(m), nil
+}
+
+func (s *server) validate(r *http.Request) (interface{}, error) {
+	var req ValidateRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	var profiles []magneturi.Profile
+	for _, name := range req.Profiles {
+		p, err := magneturi.ParseProfile(name)
+		if err != nil {
+			return nil, errorf(http.StatusBadRequest, "unknown_profile", "%v", err)
+		}
+		profiles = append(profiles, p)
+	}
+	m, err := parseURI(req.URI, req.Soft)
+	if err != nil {
+		return nil, err
+	}
+	var verr *magneturi.ValidationError
+	if err := m.Validate(profiles...); errors.As(err, &verr) {
+		return ValidateResponse{Profile: verr.Profile.String(), Violations: verr.Violations}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return ValidateResponse{Valid: true}, nil
+}
+
+func (s *server) canonicalize(r *http.Request) (interface{}, error) {
+	var req URIRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	m, err := parseURI(req.URI, req.Soft)
+	if err != nil {
+		return nil, err
+	}
+	return URIResponse{m.Canonical().String()}, nil
+}
+
+func (s *server) merge(r *http.Request) (interface{}, error) {
+	var req MergeRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	if len(req.URIs) == 0 {
+		return nil, errorf(http.StatusBadRequest, "invalid_request", "no uris to merge")
+	}
+	var links []*magneturi.MagnetURI
+	for _, raw := range req.URIs {
+		m, err := parseURI(raw, req.Soft)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, m)
+	}
+	merged, err := magneturi.Merge(links...)
+	if errors.Is(err, magneturi.ErrConflict) {
+		return nil, errorf(http.StatusConflict, "conflict", "%v", err)
+	} else if err != nil {
+		return nil, err
+	}
+	return URIResponse{merged.String()}, nil
+}
+
+func (s *server) fromTorrent(r *http.Request) (interface{}, error) {
+	var torrent []byte
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/x-bittorrent":
+		var err error
+		if torrent, err = io.ReadAll(r.Body); err != nil {
+			return nil, bodyError(err)
+		}
+	case "application/json", "":
+		var req FromTorrentRequest
+		if err := decode(r, &req); err != nil {
+			return nil, err
+		}
+		torrent = req.Torrent
+	default:
+		return nil, errorf(http.StatusUnsupportedMediaType, "unsupported_media_type",
+			"content type %q is neither application/json nor application/x-bittorrent", mediaType)
+	}
+	m, err := magneturi.FromTorrent(torrent)
+	if err != nil {
+		return nil, errorf(http.StatusUnprocessableEntity, "invalid_torrent", "%v", err)
+	}
+	return newLink(m), nil
+}
+
+// decode reads the JSON request body into v, rejecting unknown fields.
+func decode(r *http.Request, v interface{}) error {
+	d := json.NewDecoder(r.Body)
+	d.DisallowUnknownFields()
+	if err := d.Decode(v); err != nil {
+		return bodyError(err)
+	}
+	if d.More() {
+		return errorf(http.StatusBadRequest, "invalid_request", "request body has data after the JSON value")
+	}
+	return nil
+}
+
+func bodyError(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return errorf(http.StatusRequestEntityTooLarge, "request_too_large", "request body is larger than %d bytes", tooLarge.Limit)
+	}
+	return errorf(http.StatusBadRequest, "invalid_request", "invalid request body: %v", err)
+}
+
+func parseURI(raw string, soft bool) (*magneturi.MagnetURI, error) {
+	if raw == "" {
+		return nil, errorf(http.StatusBadRequest, "invalid_request", "uri is missing")
+	}
+	m, err := magneturi.Parse(raw, soft)
+	var perr *magneturi.ParseError
+	if errors.As(err, &perr) {
+		e := errorf(parseErrorStatus[perr.Kind], "invalid_magnet_uri", "%v", perr)
+		e.body.Kind, e.body.Input = perr.Kind.String(), perr.Input
+		return nil, e
+	}
+	return m, err
+}
+
+func newLink(m *magneturi.MagnetURI) Link {
+	l := Link{
+		URI:         m.String(),
+		Description: m.Describe(),
+		Params:      []Param{},
+		ExactTopics: []ExactTopic{},
+		InfoHashes:  []string{},
+		Trackers:    []Tracker{},
+	}
+	for _, p := range m.Params() {
+		decoded, err := url.QueryUnescape(p.Value)
+		if err != nil {
+			decoded = p.Value
+		}
+		l.Params = append(l.Params, Param{p.Key(), p.Prefix, p.Index, p.Value, decoded})
+	}
+	for _, t := range m.ExactTopics() {
+		l.ExactTopics = append(l.ExactTopics, ExactTopic{t.Index, t.Namespace, t.Hash, t.ValidHash()})
+	}
+	for _, h := range m.InfoHashes() {
+		l.InfoHashes = append(l.InfoHashes, h.String())
+	}
+	for _, t := range m.Trackers() {
+		l.Trackers = append(l.Trackers, Tracker{t.Index, t.URL.String(), t.Scheme, t.Host, t.Port, t.IsPrivate(), t.IsLAN(), t.IsOnion()})
+	}
+	return l
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	e := json.NewEncoder(w)
+	e.SetEscapeHTML(false)
+	e.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	var e *apiError
+	if !errors.As(err, &e) {
+		e = errorf(http.StatusInternalServerError, "internal", "%v", err)
+	}
+	writeJSON(w, e.status, ErrorResponse{e.body})
+}
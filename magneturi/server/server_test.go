@@ -0,0 +1,257 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	btih = "urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a"
+	info = "d6:lengthi3e4:name8:test.bin12:piece lengthi16384e6:pieces20:01234567890123456789e"
+)
+
+func TestServer(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		wantStatus  int
+		want        map[string]interface{}
+	}{
+		{
+			name:       "parse",
+			path:       "/parse",
+			body:       `{"uri": "magnet:?xt=` + btih + `&dn=a%20b&tr=udp%3A%2F%2F10.0.0.1%3A80"}`,
+			wantStatus: http.StatusOK,
+			want: map[string]interface{}{
+				"uri":         "magnet:?xt=" + btih + "&dn=a%20b&tr=udp%3A%2F%2F10.0.0.1%3A80",
+				"description": "Magnet URI with 3 parameters: 1 exactTopic, 1 displayName, 1 tracker",
+				"params": []interface{}{
+					map[string]interface{}{"key": "xt", "prefix": "xt", "value": btih, "decoded": btih},
+					map[string]interface{}{"key": "dn", "prefix": "dn", "value": "a%20b", "decoded": "a b"},
+					map[string]interface{}{"key": "tr", "prefix": "tr", "value": "udp%3A%2F%2F10.0.0.1%3A80", "decoded": "udp://10.0.0.1:80"},
+				},
+				"exactTopics": []interface{}{
+					map[string]interface{}{"namespace": "btih", "hash": "c12fe1c06bba254a9dc9f519b335aa7c1367a88a", "valid": true},
+				},
+				"infoHashes": []interface{}{"c12fe1c06bba254a9dc9f519b335aa7c1367a88a"},
+				"trackers": []interface{}{
+					map[string]interface{}{"url": "udp://10.0.0.1:80", "scheme": "udp", "host": "10.0.0.1", "port": 80.0, "private": true, "lan": true, "onion": false},
+				},
+			},
+		},
+		{
+			name:       "parse soft",
+			path:       "/parse",
+			body:       `{"uri": "magnet:?zz=1&dn=x", "soft": true}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "validate",
+			path:       "/validate",
+			body:       `{"uri": "magnet:?xt=` + btih + `", "profiles": ["bittorrent"]}`,
+			wantStatus: http.StatusOK,
+			want:       map[string]interface{}{"valid": true},
+		},
+		{
+			name:       "validate with violations",
+			path:       "/validate",
+			body:       `{"uri": "magnet:?xt=` + btih + `", "profiles": ["eD2k"]}`,
+			wantStatus: http.StatusOK,
+			want: map[string]interface{}{
+				"valid":   false,
+				"profile": "eD2k",
+				"violations": []interface{}{
+					"an eD2k hash exact topic (urn:ed2k) is required",
+					"an exact length (xl) is required",
+					"a display name (dn) is required",
+				},
+			},
+		},
+		{
+			name:       "canonicalize",
+			path:       "/canonicalize",
+			body:       `{"uri": "magnet:?dn=x&xt=URN:BTIH:YEX6DQDLXISUVHOJ6UM3GNNKPQJWPKEK"}`,
+			wantStatus: http.StatusOK,
+			want:       map[string]interface{}{"uri": "magnet:?xt=" + btih + "&dn=x"},
+		},
+		{
+			name:       "merge",
+			path:       "/merge",
+			body:       `{"uris": ["magnet:?xt=` + btih + `&tr=http://a", "magnet:?xt=` + btih + `&tr=http://b"]}`,
+			wantStatus: http.StatusOK,
+			want:       map[string]interface{}{"uri": "magnet:?xt=" + btih + "&tr=http://a&tr=http://b"},
+		},
+		{
+			name:       "from-torrent JSON",
+			path:       "/from-torrent",
+			body:       `{"torrent": "ZDg6YW5ub3VuY2U4Omh0dHA6Ly9hZQ=="}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			want:       errorBody("invalid_torrent", "invalid torrent: no info dictionary", "", ""),
+		},
+		{
+			name:        "from-torrent file",
+			path:        "/from-torrent",
+			contentType: "application/x-bittorrent",
+			body:        "d4:info" + info + "e",
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:        "from-torrent other content type",
+			path:        "/from-torrent",
+			contentType: "text/plain",
+			body:        "x",
+			wantStatus:  http.StatusUnsupportedMediaType,
+		},
+		{
+			name:       "not a magnet link",
+			path:       "/parse",
+			body:       `{"uri": "http://example.org"}`,
+			wantStatus: http.StatusBadRequest,
+			want: errorBody("invalid_magnet_uri", `uri doesn't start with the Magnet URI schema prefix "magnet:?"`,
+				"scheme", "http://example.org"),
+		},
+		{
+			name:       "invalid prefix",
+			path:       "/canonicalize",
+			body:       `{"uri": "magnet:?zz=1"}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			want:       errorBody("invalid_magnet_uri", `invalid parameter prefix: "zz"`, "prefix", "zz=1"),
+		},
+		{
+			name:       "unknown profile",
+			path:       "/validate",
+			body:       `{"uri": "magnet:?xt=` + btih + `", "profiles": ["kazaa"]}`,
+			wantStatus: http.StatusBadRequest,
+			want:       errorBody("unknown_profile", `unknown validation profile: "kazaa"`, "", ""),
+		},
+		{
+			name:       "merge conflict",
+			path:       "/merge",
+			body:       `{"uris": ["magnet:?xt=` + btih + `", "magnet:?xt=urn:ed2k:354B15E68FB8F36D7CD88FF94116CDC1"]}`,
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:       "missing uri",
+			path:       "/parse",
+			body:       `{}`,
+			wantStatus: http.StatusBadRequest,
+			want:       errorBody("invalid_request", "uri is missing", "", ""),
+		},
+		{
+			name:       "unknown field",
+			path:       "/parse",
+			body:       `{"url": "magnet:?"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "body too large",
+			path:       "/parse",
+			body:       `{"uri": "magnet:?dn=` + strings.Repeat("x", 300) + `"}`,
+			wantStatus: http.StatusRequestEntityTooLarge,
+			want:       errorBody("request_too_large", "request body is larger than 256 bytes", "", ""),
+		},
+		{
+			name:       "wrong method",
+			method:     http.MethodGet,
+			path:       "/parse",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "unknown path",
+			path:       "/nope",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+	h := New(Options{MaxBodySize: 256})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			req := httptest.NewRequest(method, tt.path, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q", ct)
+			}
+			var got map[string]interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("invalid JSON %s: %v", rec.Body, err)
+			}
+			if tt.want != nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("body = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func errorBody(code, message, kind, input string) map[string]interface{} {
+	e := map[string]interface{}{"code": code, "message": message}
+	if kind != "" {
+		e["kind"] = kind
+	}
+	if input != "" {
+		e["input"] = input
+	}
+	return map[string]interface{}{"error": e}
+}
+
+func TestOpenAPI(t *testing.T) {
+	b, err := OpenAPI()
+	if err != nil {
+		t.Fatalf("OpenAPI() error = %v", err)
+	}
+	var spec struct {
+		OpenAPI    string                            `json:"openapi"`
+		Paths      map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]interface{} `json:"properties"`
+				Required   []string               `json:"required"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(b, &spec); err != nil {
+		t.Fatalf("OpenAPI() is not JSON: %v", err)
+	}
+	if spec.OpenAPI != "3.0.3" {
+		t.Errorf("openapi = %q", spec.OpenAPI)
+	}
+	for _, path := range []string{"/parse", "/validate", "/canonicalize", "/merge", "/from-torrent"} {
+		if _, ok := spec.Paths[path]["post"]; !ok {
+			t.Errorf("no POST %s in the spec", path)
+		}
+	}
+	link := spec.Components.Schemas["Link"]
+	if _, ok := link.Properties["exactTopics"]; !ok {
+		t.Errorf("Link schema = %v, want an exactTopics property", link)
+	}
+	if want := []string{"uri"}; !reflect.DeepEqual(spec.Components.Schemas["URIRequest"].Required, want) {
+		t.Errorf("URIRequest required = %v, want %v", spec.Components.Schemas["URIRequest"].Required, want)
+	}
+	for _, name := range []string{"ErrorResponse", "Error", "Param", "ExactTopic", "Tracker", "MergeRequest"} {
+		if _, ok := spec.Components.Schemas[name]; !ok {
+			t.Errorf("no %s schema in the spec", name)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	New(Options{}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK || !json.Valid(rec.Body.Bytes()) {
+		t.Errorf("GET /openapi.json = %d %s", rec.Code, rec.Body)
+	}
+}
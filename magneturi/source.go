@@ -0,0 +1,59 @@
+package magneturi
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Source is an exact source (xs) or acceptable source (as) that is a
+// URL with a host.
+type Source struct {
+	// Prefix is "xs" or "as".
+	Prefix string
+	// Index is the xs.N or as.N index, if any.
+	Index string
+	// Raw is the value as it appeared in the link.
+	Raw string
+	// URL is the decoded URL.
+	URL *url.URL
+	// Host is the host of the URL in ASCII and Unicode form.
+	Host Host
+}
+
+// Sources returns the exact and acceptable sources of the link that
+// are URLs with a valid host, in link order. Other sources, such as
+// urn:btpk: values, are skipped.
+func (m *MagnetURI) Sources() []Source {
+	var sources []Source
+	for _, p := range m.params {
+		if p.prefix != "xs" && p.prefix != "as" {
+			continue
+		}
+		u := sourceURL(p.value)
+		if u == nil || u.Hostname() == "" {
+			continue
+		}
+		host, err := ParseHost(u.Hostname())
+		if err != nil {
+			continue
+		}
+		sources = append(sources, Source{p.prefix, p.index, p.value, u, host})
+	}
+	return sources
+}
+
+// sourceURL parses an xs or as value, nil if it is not a URL with a
+// scheme.
+func sourceURL(value string) *url.URL {
+	if !strings.Contains(value, "://") {
+		if decoded, err := url.QueryUnescape(value); err == nil {
+			value = decoded
+		}
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" {
+		return nil
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	return u
+}
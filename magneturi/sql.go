@@ -0,0 +1,65 @@
+package magneturi
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// Value stores the link as its canonical string, see Canonical, so
+// equal links compare equal in the database. A nil link is NULL.
+func (m *MagnetURI) Value() (driver.Value, error) {
+	if m == nil {
+		return nil, nil
+	}
+	return m.Canonical().String(), nil
+}
+
+// Scan parses a link stored as text, replacing the parameters of m.
+func (m *MagnetURI) Scan(src interface{}) error {
+	var raw string
+	switch src := src.(type) {
+	case string:
+		raw = src
+	case []byte:
+		raw = string(src)
+	case nil:
+		return fmt.Errorf("cannot scan NULL into a MagnetURI")
+	default:
+		return fmt.Errorf("cannot scan %T into a MagnetURI", src)
+	}
+	parsed, err := Parse(raw, false)
+	if err != nil {
+		return err
+	}
+	*m = *parsed
+	return nil
+}
+
+// Value stores the info-hash as its 20 bytes, for compact binary
+// columns (BYTEA, BLOB, BINARY(20)).
+func (h InfoHash) Value() (driver.Value, error) {
+	return h[:], nil
+}
+
+// Scan reads an info-hash stored as 20 bytes or as text in hex or
+// base32.
+func (h *InfoHash) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case []byte:
+		if len(src) == len(h) {
+			copy(h[:], src)
+			return nil
+		}
+		return h.Scan(string(src))
+	case string:
+		parsed, err := ParseInfoHash(src)
+		if err != nil {
+			return err
+		}
+		*h = parsed
+		return nil
+	case nil:
+		return fmt.Errorf("cannot scan NULL into an InfoHash")
+	}
+	return fmt.Errorf("cannot scan %T into an InfoHash", src)
+}
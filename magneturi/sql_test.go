@@ -0,0 +1,206 @@
+package magneturi
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// memDriver is a stand-in for an SQL database: INSERT appends its
+// arguments as a row of the table named by the DSN, SELECT returns
+// the rows.
+type memDriver struct {
+	mu     sync.Mutex
+	tables map[string][][]driver.Value
+}
+
+var testDB = &memDriver{tables: map[string][][]driver.Value{}}
+
+func init() {
+	sql.Register("magneturi-mem", testDB)
+}
+
+func (d *memDriver) Open(name string) (driver.Conn, error) {
+	return &memConn{d, name}, nil
+}
+
+type memConn struct {
+	d     *memDriver
+	table string
+}
+
+func (c *memConn) Prepare(query string) (driver.Stmt, error) {
+	return &memStmt{c, query}, nil
+}
+
+func (c *memConn) Close() error { return nil }
+
+func (c *memConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+type memStmt struct {
+	c     *memConn
+	query string
+}
+
+func (s *memStmt) Close() error  { return nil }
+func (s *memStmt) NumInput() int { return -1 }
+
+func (s *memStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if !strings.HasPrefix(s.query, "INSERT") {
+		return nil, errors.New("only INSERT is supported")
+	}
+	row := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if b, ok := arg.([]byte); ok {
+			arg = append([]byte{}, b...)
+		}
+		row[i] = arg
+	}
+	s.c.d.mu.Lock()
+	defer s.c.d.mu.Unlock()
+	s.c.d.tables[s.c.table] = append(s.c.d.tables[s.c.table], row)
+	return driver.RowsAffected(1), nil
+}
+
+func (s *memStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if !strings.HasPrefix(s.query, "SELECT") {
+		return nil, errors.New("only SELECT is supported")
+	}
+	s.c.d.mu.Lock()
+	defer s.c.d.mu.Unlock()
+	return &memRows{rows: s.c.d.tables[s.c.table]}, nil
+}
+
+type memRows struct {
+	rows [][]driver.Value
+}
+
+func (r *memRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	return make([]string, len(r.rows[0]))
+}
+
+func (r *memRows) Close() error { return nil }
+
+func (r *memRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestMagnetURI_sql(t *testing.T) {
+	db, err := sql.Open("magneturi-mem", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	m := MustParse("magnet:?dn=name&xt=urn:btih:QHQXPYWMACKDWKP47RRVIV7VOURXFE5Q&tr=http://a&tr=http://a/")
+	h := m.InfoHashes()[0]
+	if _, err := db.Exec("INSERT INTO links VALUES (?, ?)", m, h); err != nil {
+		t.Fatalf("INSERT error = %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO links VALUES (?, ?)", "magnet:?dn=text", "81E177E2CC00943B29FCFC635457F575237293B0"); err != nil {
+		t.Fatalf("INSERT error = %v", err)
+	}
+
+	stored := testDB.tables[t.Name()][0]
+	if want := "magnet:?xt=urn:btih:81e177e2cc00943b29fcfc635457f575237293b0&dn=name&tr=http://a"; stored[0] != want {
+		t.Errorf("stored link = %q, want the canonical %q", stored[0], want)
+	}
+	if b, ok := stored[1].([]byte); !ok || len(b) != 20 {
+		t.Errorf("stored info-hash = %#v, want 20 bytes", stored[1])
+	}
+
+	rows, err := db.Query("SELECT link, hash FROM links")
+	if err != nil {
+		t.Fatalf("SELECT error = %v", err)
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var link MagnetURI
+		var hash InfoHash
+		if err := rows.Scan(&link, &hash); err != nil {
+			t.Fatalf("Scan() error = %v", err)
+		}
+		if hash != h {
+			t.Errorf("Scan() info-hash = %v, want %v", hash, h)
+		}
+		got = append(got, link.String())
+	}
+	if want := []string{m.Canonical().String(), "magnet:?dn=text"}; strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("Scan() links = %v, want %v", got, want)
+	}
+}
+
+func TestMagnetURI_Scan(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     interface{}
+		want    string
+		wantErr bool
+	}{
+		{"string", "magnet:?dn=a", "magnet:?dn=a", false},
+		{"bytes", []byte("magnet:?dn=a"), "magnet:?dn=a", false},
+		{"empty link", "magnet:?", "magnet:?", false},
+		{"not a link", "http://a", "", true},
+		{"NULL", nil, "", true},
+		{"integer", int64(1), "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := MustParse("magnet:?dn=old")
+			err := m.Scan(tt.src)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Scan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && m.String() != tt.want {
+				t.Errorf("Scan() = %s, want %s", m, tt.want)
+			}
+		})
+	}
+	if v, err := (*MagnetURI)(nil).Value(); v != nil || err != nil {
+		t.Errorf("Value() of a nil link = %v, %v, want NULL", v, err)
+	}
+}
+
+func TestInfoHash_Scan(t *testing.T) {
+	want, _ := ParseInfoHash("81e177e2cc00943b29fcfc635457f575237293b0")
+	tests := []struct {
+		name    string
+		src     interface{}
+		wantErr bool
+	}{
+		{"bytes", want[:], false},
+		{"hex", "81e177e2cc00943b29fcfc635457f575237293b0", false},
+		{"hex bytes", []byte("81e177e2cc00943b29fcfc635457f575237293b0"), false},
+		{"base32", "QHQXPYWMACKDWKP47RRVIV7VOURXFE5Q", false},
+		{"short", []byte{1, 2}, true},
+		{"NULL", nil, true},
+		{"integer", int64(1), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var h InfoHash
+			err := h.Scan(tt.src)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Scan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && h != want {
+				t.Errorf("Scan() = %v, want %v", h, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package magneturi
+
+import (
+	"encoding/binary"
+	"hash"
+)
+
+// tiger is the original 192 bit Tiger hash by Anderson and Biham, the
+// one Tiger Tree Hashes (urn:tree:tiger) are built from.
+type tiger struct {
+	s   [3]uint64
+	buf [64]byte
+	nx  int
+	len uint64
+}
+
+// tigerSBoxes are the four 256 entry S-boxes, t1..t4 back to back.
+// Rather than carrying 1024 constants they are derived at start up
+// with the generation procedure published with the reference code.
+var tigerSBoxes = genTigerSBoxes()
+
+func newTiger() hash.Hash {
+	d := &tiger{}
+	d.Reset()
+	return d
+}
+
+var tigerIV = [3]uint64{0x0123456789ABCDEF, 0xFEDCBA9876543210, 0xF096A5B4C3B2E187}
+
+func (d *tiger) Reset() {
+	d.s = tigerIV
+	d.nx = 0
+	d.len = 0
+}
+
+func (d *tiger) Size() int      { return 24 }
+func (d *tiger) BlockSize() int { return 64 }
+
+func (d *tiger) Write(p []byte) (int, error) {
+	n := len(p)
+	d.len += uint64(n)
+	if d.nx > 0 {
+		c := copy(d.buf[d.nx:], p)
+		d.nx += c
+		p = p[c:]
+		if d.nx == 64 {
+			tigerCompress(&tigerSBoxes, &d.s, d.buf[:])
+			d.nx = 0
+		}
+	}
+	for len(p) >= 64 {
+		tigerCompress(&tigerSBoxes, &d.s, p[:64])
+		p = p[64:]
+	}
+	d.nx += copy(d.buf[:], p)
+	return n, nil
+}
+
+func (d *tiger) Sum(in []byte) []byte {
+	c := *d
+	var pad [72]byte
+	//Tiger pads with 0x01 where MD4 and friends use 0x80
+	pad[0] = 0x01
+	padLen := 56 - c.len%64
+	if c.len%64 >= 56 {
+		padLen += 64
+	}
+	binary.LittleEndian.PutUint64(pad[padLen:], c.len<<3)
+	c.Write(pad[:padLen+8])
+	for _, s := range c.s {
+		in = binary.LittleEndian.AppendUint64(in, s)
+	}
+	return in
+}
+
+func tigerCompress(t *[1024]uint64, s *[3]uint64, block []byte) {
+	var x [8]uint64
+	for i := range x {
+		x[i] = binary.LittleEndian.Uint64(block[i*8:])
+	}
+	a, b, c := s[0], s[1], s[2]
+	tigerPass(t, &a, &b, &c, &x, 5)
+	tigerKeySchedule(&x)
+	tigerPass(t, &c, &a, &b, &x, 7)
+	tigerKeySchedule(&x)
+	tigerPass(t, &b, &c, &a, &x, 9)
+	s[0] ^= a
+	s[1] = b - s[1]
+	s[2] += c
+}
+
+func tigerRound(t *[1024]uint64, a, b, c *uint64, x, mul uint64) {
+	*c ^= x
+	cc := *c
+	*a -= t[cc&0xff] ^ t[256+(cc>>16)&0xff] ^ t[512+(cc>>32)&0xff] ^ t[768+(cc>>48)&0xff]
+	*b += t[768+(cc>>8)&0xff] ^ t[512+(cc>>24)&0xff] ^ t[256+(cc>>40)&0xff] ^ t[cc>>56]
+	*b *= mul
+}
+
+func tigerPass(t *[1024]uint64, a, b, c *uint64, x *[8]uint64, mul uint64) {
+	tigerRound(t, a, b, c, x[0], mul)
+	tigerRound(t, b, c, a, x[1], mul)
+	tigerRound(t, c, a, b, x[2], mul)
+	tigerRound(t, a, b, c, x[3], mul)
+	tigerRound(t, b, c, a, x[4], mul)
+	tigerRound(t, c, a, b, x[5], mul)
+	tigerRound(t, a, b, c, x[6], mul)
+	tigerRound(t, b, c, a, x[7], mul)
+}
+
+func tigerKeySchedule(x *[8]uint64) {
+	x[0] -= x[7] ^ 0xA5A5A5A5A5A5A5A5
+	x[1] ^= x[0]
+	x[2] += x[1]
+	x[3] -= x[2] ^ (^x[1] << 19)
+	x[4] ^= x[3]
+	x[5] += x[4]
+	x[6] -= x[5] ^ (^x[4] >> 23)
+	x[7] ^= x[6]
+	x[0] += x[7]
+	x[1] -= x[0] ^ (^x[7] << 19)
+	x[2] ^= x[1]
+	x[3] += x[2]
+	x[4] -= x[3] ^ (^x[2] >> 23)
+	x[5] ^= x[4]
+	x[6] += x[5]
+	x[7] -= x[6] ^ 0x0123456789ABCDEF
+}
+
+func genTigerSBoxes() [1024]uint64 {
+	const seed = "Tiger - A Fast New Hash Function, by Ross Anderson and Eli Biham"
+	var t [1024]uint64
+	for i := range t {
+		t[i] = uint64(i&255) * 0x0101010101010101
+	}
+	state := tigerIV
+	abc := 2
+	for pass := 0; pass < 5; pass++ {
+		for i := 0; i < 256; i++ {
+			for sb := 0; sb < 1024; sb += 256 {
+				abc++
+				if abc == 3 {
+					abc = 0
+					tigerCompress(&t, &state, []byte(seed))
+				}
+				for col := uint(0); col < 8; col++ {
+					j := sb + int(byte(state[abc]>>(8*col)))
+					mask := uint64(0xff) << (8 * col)
+					bi, bj := t[sb+i]&mask, t[j]&mask
+					t[sb+i] = t[sb+i]&^mask | bj
+					t[j] = t[j]&^mask | bi
+				}
+			}
+		}
+	}
+	return t
+}
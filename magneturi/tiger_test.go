@@ -0,0 +1,29 @@
+package magneturi
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func Test_tiger(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", "3293ac630c13f0245f92bbb1766e16167a4e58492dde73f3"},
+		{"a", "77befbef2e7ef8ab2ec8f93bf587a7fc613e247f5f247809"},
+		{"abc", "2aab1484e8c158f2bfb8c5ff41b57a525129131c957b5f93"},
+		{"message digest", "d981f8cb78201a950dcf3048751e441c517fca1aa55a29f6"},
+		{"Tiger", "dd00230799f5009fec6debc838bb6a27df2b9d6f110c7937"},
+		{strings.Repeat("1234567890", 8), "1c14795529fd9f207a958f84c52f11e887fa0cabdfd91bfd"},
+	}
+	for _, tt := range tests {
+		d := newTiger()
+		d.Write([]byte(tt.in[:len(tt.in)/2]))
+		d.Write([]byte(tt.in[len(tt.in)/2:]))
+		if got := hex.EncodeToString(d.Sum(nil)); got != tt.want {
+			t.Errorf("tiger(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package magneturi
+
+import (
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const urnPrefix = "urn:"
+
+// ExactTopic is a parsed exact topic (xt) value of the form
+// urn:<Namespace>:<Hash>, e.g. urn:tree:tiger:<Hash> has the
+// Namespace "tree:tiger".
+type ExactTopic struct {
+	Index     string
+	Namespace string
+	Hash      string
+}
+
+// hashFormats lists the accepted encodings per namespace as the
+// length of the hash string and the alphabet it is written in.
+var hashFormats = map[string][]struct {
+	length   int
+	alphabet string
+}{
+	"btih":       {{40, "hex"}, {32, "base32"}},
+	"btmh":       {{68, "hex"}},
+	"ed2k":       {{32, "hex"}},
+	"sha1":       {{32, "base32"}, {40, "hex"}},
+	"tree:tiger": {{39, "base32"}},
+	"bitprint":   {{72, "bitprint"}},
+	"md5":        {{32, "hex"}},
+	"aich":       {{32, "base32"}},
+}
+
+func parseExactTopic(index, value string) (ExactTopic, error) {
+	if unescaped, err := url.QueryUnescape(value); err == nil {
+		value = unescaped
+	}
+	if !strings.HasPrefix(strings.ToLower(value), urnPrefix) {
+		return ExactTopic{}, fmt.Errorf("exact topic is not a urn: %q", value)
+	}
+	urn := value[len(urnPrefix):]
+	i := strings.LastIndex(urn, ":")
+	if i <= 0 || i == len(urn)-1 {
+		return ExactTopic{}, fmt.Errorf("exact topic without namespace or hash: %q", value)
+	}
+	return ExactTopic{index, strings.ToLower(urn[:i]), urn[i+1:]}, nil
+}
+
+// String returns the urn form of the topic.
+func (t ExactTopic) String() string {
+	return urnPrefix + t.Namespace + ":" + t.Hash
+}
+
+// ValidHash reports whether Hash is well formed for a known Namespace.
+// Topics with an unknown namespace are never valid.
+func (t ExactTopic) ValidHash() bool {
+	for _, f := range hashFormats[t.Namespace] {
+		if len(t.Hash) == f.length && isEncoded(t.Hash, f.alphabet) {
+			return true
+		}
+	}
+	return false
+}
+
+func isEncoded(s, alphabet string) bool {
+	switch alphabet {
+	case "hex":
+		_, err := hex.DecodeString(s)
+		return err == nil
+	case "base32":
+		_, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(s))
+		return err == nil
+	case "bitprint":
+		//sha1 and tiger tree root joined by a dot
+		parts := strings.Split(s, ".")
+		return len(parts) == 2 &&
+			len(parts[0]) == 32 && isEncoded(parts[0], "base32") &&
+			len(parts[1]) == 39 && isEncoded(parts[1], "base32")
+	}
+	return false
+}
+
+// hashKey identifies the hash within the namespace: the decoded bytes
+// of a well formed hash, so that the hex and base32 forms or upper and
+// lower case of the same hash are equal, otherwise the lower cased
+// string.
+func (t ExactTopic) hashKey() string {
+	for _, f := range hashFormats[t.Namespace] {
+		if len(t.Hash) != f.length {
+			continue
+		}
+		var b []byte
+		var err error
+		switch f.alphabet {
+		case "hex":
+			b, err = hex.DecodeString(t.Hash)
+		case "base32":
+			b, err = base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(t.Hash))
+		default:
+			continue
+		}
+		if err == nil {
+			return string(b)
+		}
+	}
+	return strings.ToLower(t.Hash)
+}
+
+// ExactTopics returns the parsed xt parameters in order, values that
+// are not a urn are skipped.
+func (m *MagnetURI) ExactTopics() []ExactTopic {
+	var topics []ExactTopic
+	for _, p := range m.params {
+		if p.prefix != "xt" {
+			continue
+		}
+		if t, err := parseExactTopic(p.index, p.value); err == nil {
+			topics = append(topics, t)
+		}
+	}
+	return topics
+}
+
+func (m *MagnetURI) hasTopic(namespaces ...string) bool {
+	for _, t := range m.ExactTopics() {
+		for _, ns := range namespaces {
+			if t.Namespace == ns && t.ValidHash() {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package magneturi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_parseExactTopic(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    ExactTopic
+		wantErr bool
+	}{
+		{
+			name:  "btih",
+			value: "urn:btih:QHQXPYWMACKDWKP47RRVIV7VOURXFE5Q",
+			want:  ExactTopic{"", "btih", "QHQXPYWMACKDWKP47RRVIV7VOURXFE5Q"},
+		},
+		{
+			name:  "tree tiger",
+			value: "urn:tree:tiger:7N5OAMRNGMSSEUE3ORHOKWN4WWIQ5X4EBOOTLJY",
+			want:  ExactTopic{"", "tree:tiger", "7N5OAMRNGMSSEUE3ORHOKWN4WWIQ5X4EBOOTLJY"},
+		},
+		{
+			name:  "percent encoded",
+			value: "urn%3Aed2k%3A354B15E68FB8F36D7CD88FF94116CDC1",
+			want:  ExactTopic{"", "ed2k", "354B15E68FB8F36D7CD88FF94116CDC1"},
+		},
+		{
+			name:    "not a urn",
+			value:   "http://example.org",
+			wantErr: true,
+		},
+		{
+			name:    "no hash",
+			value:   "urn:btih:",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseExactTopic("", tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseExactTopic() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseExactTopic() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExactTopic_ValidHash(t *testing.T) {
+	tests := []struct {
+		name  string
+		topic ExactTopic
+		want  bool
+	}{
+		{"btih hex", ExactTopic{"", "btih", "c12fe1c06bba254a9dc9f519b335aa7c1367a88a"}, true},
+		{"btih base32", ExactTopic{"", "btih", "QHQXPYWMACKDWKP47RRVIV7VOURXFE5Q"}, true},
+		{"btih short", ExactTopic{"", "btih", "QHQXPYWMACKDWKP47RRV"}, false},
+		{"ed2k", ExactTopic{"", "ed2k", "354B15E68FB8F36D7CD88FF94116CDC1"}, true},
+		{"ed2k not hex", ExactTopic{"", "ed2k", "354B15E68FB8F36D7CD88FF94116CDCZ"}, false},
+		{"tiger", ExactTopic{"", "tree:tiger", "7N5OAMRNGMSSEUE3ORHOKWN4WWIQ5X4EBOOTLJY"}, true},
+		{"unknown", ExactTopic{"", "foo", "bar"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.topic.ValidHash(); got != tt.want {
+				t.Errorf("ExactTopic.ValidHash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMagnetURI_ExactTopics(t *testing.T) {
+	m := &MagnetURI{
+		params: []param{
+			param{"xt", "1", "urn:ed2k:354B15E68FB8F36D7CD88FF94116CDC1"},
+			param{"dn", "", "mediawiki-1.15.1.tar.gz"},
+			param{"xt", "2", "not-a-urn"},
+			param{"xt", "3", "urn:btih:QHQXPYWMACKDWKP47RRVIV7VOURXFE5Q"},
+		},
+	}
+	want := []ExactTopic{
+		{"1", "ed2k", "354B15E68FB8F36D7CD88FF94116CDC1"},
+		{"3", "btih", "QHQXPYWMACKDWKP47RRVIV7VOURXFE5Q"},
+	}
+	if got := m.ExactTopics(); !reflect.DeepEqual(got, want) {
+		t.Errorf("MagnetURI.ExactTopics() = %v, want %v", got, want)
+	}
+}
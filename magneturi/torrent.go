@@ -0,0 +1,186 @@
+package magneturi
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/nmmh/magneturi/magneturi/bencode"
+)
+
+// TorrentOptions are the descriptive fields of a .torrent file.
+type TorrentOptions struct {
+	// CreatedBy names the program, omitted if empty.
+	CreatedBy string
+	// Comment is free text, omitted if empty.
+	Comment string
+	// CreationDate is omitted if zero, which keeps the output
+	// reproducible.
+	CreationDate time.Time
+}
+
+// DefaultTorrentOptions are what ToTorrent uses.
+var DefaultTorrentOptions = TorrentOptions{CreatedBy: "magneturi"}
+
+// ToTorrent is ToTorrentWith using DefaultTorrentOptions.
+func ToTorrent(m *MagnetURI, info []byte) ([]byte, error) {
+	return ToTorrentWith(m, info, DefaultTorrentOptions)
+}
+
+// ToTorrentWith bencodes a .torrent metainfo dictionary from info, the
+// bencoded info dictionary of the torrent, and the trackers (tr) and
+// web seeds (ws) of m. It refuses info that does not hash to every
+// btih and btmh exact topic of m.
+func ToTorrentWith(m *MagnetURI, info []byte, opts TorrentOptions) ([]byte, error) {
+	if err := checkInfoHashes(m, info); err != nil {
+		return nil, err
+	}
+	v, err := bencode.Decode(info)
+	if err != nil {
+		return nil, fmt.Errorf("invalid info dictionary: %v", err)
+	}
+	if _, ok := v.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("invalid info dictionary: not a dictionary")
+	}
+	torrent := map[string]interface{}{"info": bencode.RawMessage(info)}
+	list := m.AnnounceList()
+	if trackers := list.Trackers(); len(trackers) > 0 {
+		torrent["announce"] = trackers[0].String()
+		if len(trackers) > 1 {
+			torrent["announce-list"] = list
+		}
+	}
+	var webSeeds []string
+	for _, p := range m.params {
+		if p.prefix != "ws" {
+			continue
+		}
+		ws := p.value
+		if decoded, err := url.QueryUnescape(ws); err == nil {
+			ws = decoded
+		}
+		webSeeds = append(webSeeds, ws)
+	}
+	if len(webSeeds) > 0 {
+		torrent["url-list"] = webSeeds
+	}
+	if opts.CreatedBy != "" {
+		torrent["created by"] = opts.CreatedBy
+	}
+	if opts.Comment != "" {
+		torrent["comment"] = opts.Comment
+	}
+	if !opts.CreationDate.IsZero() {
+		torrent["creation date"] = opts.CreationDate.Unix()
+	}
+	return bencode.Encode(torrent)
+}
+
+// checkInfoHashes makes sure info belongs to the BitTorrent topics of
+// m, of which there has to be at least one.
+func checkInfoHashes(m *MagnetURI, info []byte) error {
+	v1 := sha1.Sum(info)
+	v2 := sha256.Sum256(info)
+	found := false
+	for _, t := range m.ExactTopics() {
+		var want []byte
+		switch t.Namespace {
+		case string(BTIH):
+			want = v1[:]
+		case string(BTMH):
+			want = append([]byte{0x12, 0x20}, v2[:]...)
+		default:
+			continue
+		}
+		found = true
+		if !bytes.Equal(decodeHash(t.Hash), want) {
+			return fmt.Errorf("info dictionary does not match the exact topic %q", t.String())
+		}
+	}
+	if !found {
+		return fmt.Errorf("the Magnet URI has no BitTorrent exact topic (urn:btih or urn:btmh)")
+	}
+	return nil
+}
+
+// metainfo is the part of a .torrent file FromTorrent reads.
+type metainfo struct {
+	Announce     string             `bencode:"announce"`
+	AnnounceList [][]string         `bencode:"announce-list"`
+	Info         bencode.RawMessage `bencode:"info"`
+	URLList      interface{}        `bencode:"url-list"`
+}
+
+type metainfoInfo struct {
+	Name        string `bencode:"name"`
+	Length      int64  `bencode:"length"`
+	MetaVersion int    `bencode:"meta version"`
+	Pieces      string `bencode:"pieces"`
+}
+
+// FromTorrent builds a Magnet URI from a .torrent file: a btih exact
+// topic for v1 and hybrid torrents and a btmh one for v2 and hybrid
+// torrents, the name (dn), the length of a single file torrent (xl),
+// the trackers of the announce-list as tr.N with N the tier number or
+// else the announce URL as tr, and the url-list web seeds (ws).
+func FromTorrent(data []byte) (*MagnetURI, error) {
+	var t metainfo
+	if err := bencode.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("invalid torrent: %v", err)
+	}
+	if len(t.Info) == 0 {
+		return nil, fmt.Errorf("invalid torrent: no info dictionary")
+	}
+	var info metainfoInfo
+	if err := bencode.Unmarshal(t.Info, &info); err != nil {
+		return nil, fmt.Errorf("invalid torrent info dictionary: %v", err)
+	}
+	var topics []string
+	if info.MetaVersion != 2 || info.Pieces != "" {
+		v1 := sha1.Sum(t.Info)
+		topics = append(topics, "urn:btih:"+hex.EncodeToString(v1[:]))
+	}
+	if info.MetaVersion == 2 {
+		v2 := sha256.Sum256(t.Info)
+		topics = append(topics, "urn:btmh:1220"+hex.EncodeToString(v2[:]))
+	}
+	m := &MagnetURI{}
+	for i, xt := range topics {
+		index := ""
+		if len(topics) > 1 {
+			index = strconv.Itoa(i + 1)
+		}
+		m.params = append(m.params, param{"xt", index, xt})
+	}
+	if info.Length > 0 {
+		m.params = append(m.params, param{"xl", "", strconv.FormatInt(info.Length, 10)})
+	}
+	if info.Name != "" {
+		m.params = append(m.params, param{"dn", "", url.QueryEscape(info.Name)})
+	}
+	if len(t.AnnounceList) > 0 {
+		for i, tier := range t.AnnounceList {
+			for _, tr := range tier {
+				m.params = append(m.params, param{"tr", strconv.Itoa(i + 1), url.QueryEscape(tr)})
+			}
+		}
+	} else if t.Announce != "" {
+		m.params = append(m.params, param{"tr", "", url.QueryEscape(t.Announce)})
+	}
+	switch ws := t.URLList.(type) {
+	case string:
+		m.params = append(m.params, param{"ws", "", url.QueryEscape(ws)})
+	case []interface{}:
+		for _, w := range ws {
+			if s, ok := w.(string); ok {
+				m.params = append(m.params, param{"ws", "", url.QueryEscape(s)})
+			}
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,162 @@
+package magneturi
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+)
+
+const testInfo = "d6:lengthi3e4:name8:test.bin12:piece lengthi16384e6:pieces20:01234567890123456789e"
+
+func TestToTorrent(t *testing.T) {
+	v1 := sha1.Sum([]byte(testInfo))
+	v2 := sha256.Sum256([]byte(testInfo))
+	btih := "urn:btih:" + hex.EncodeToString(v1[:])
+	btmh := "urn:btmh:1220" + hex.EncodeToString(v2[:])
+	tests := []struct {
+		name    string
+		raw     string
+		info    string
+		opts    TorrentOptions
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "info only",
+			raw:  "magnet:?xt=" + btih,
+			info: testInfo,
+			opts: TorrentOptions{},
+			want: "d4:info" + testInfo + "e",
+		},
+		{
+			name: "trackers, web seeds and descriptive fields",
+			raw: "magnet:?xt.1=" + btih + "&xt.2=" + btmh +
+				"&tr=udp%3A%2F%2Fa.example.org%3A80&tr=http://b.example.org/announce&ws=http%3A%2F%2Fseed.example.org%2Ftest.bin",
+			info: testInfo,
+			opts: TorrentOptions{CreatedBy: "magneturi", Comment: "hi", CreationDate: time.Unix(1500000000, 0)},
+			want: "d8:announce22:udp://a.example.org:80" +
+				"13:announce-listll22:udp://a.example.org:80el29:http://b.example.org/announceee" +
+				"7:comment2:hi10:created by9:magneturi13:creation datei1500000000e" +
+				"4:info" + testInfo +
+				"8:url-listl32:http://seed.example.org/test.binee",
+		},
+		{
+			name:    "info-hash mismatch",
+			raw:     "magnet:?xt=urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a",
+			info:    testInfo,
+			wantErr: true,
+		},
+		{
+			name:    "btmh mismatch",
+			raw:     "magnet:?xt.1=" + btih + "&xt.2=urn:btmh:1220caf1e1c30e81cb361b9ee167c4aa64228a7fa4fa9f6105232b28ad099f3a302e",
+			info:    testInfo,
+			wantErr: true,
+		},
+		{
+			name:    "no BitTorrent topic",
+			raw:     "magnet:?xt=urn:ed2k:354B15E68FB8F36D7CD88FF94116CDC1",
+			info:    testInfo,
+			wantErr: true,
+		},
+		{
+			name:    "info not a dictionary",
+			raw:     "magnet:?xt=urn:btih:" + hex.EncodeToString(sha1Sum("i1e")),
+			info:    "i1e",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToTorrentWith(MustParse(tt.raw), []byte(tt.info), tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ToTorrentWith() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ToTorrentWith() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func sha1Sum(s string) []byte {
+	sum := sha1.Sum([]byte(s))
+	return sum[:]
+}
+
+func TestToTorrent_defaults(t *testing.T) {
+	v1 := sha1.Sum([]byte(testInfo))
+	got, err := ToTorrent(MustParse("magnet:?xt=urn:btih:"+hex.EncodeToString(v1[:])), []byte(testInfo))
+	if err != nil {
+		t.Fatalf("ToTorrent() error = %v", err)
+	}
+	if want := "d10:created by9:magneturi4:info" + testInfo + "e"; string(got) != want {
+		t.Errorf("ToTorrent() = %s, want %s", got, want)
+	}
+}
+
+func TestFromTorrent(t *testing.T) {
+	v1 := sha1.Sum([]byte(testInfo))
+	btih := "urn:btih:" + hex.EncodeToString(v1[:])
+	v2Info := "d9:file treed1:ad0:d6:lengthi0eeee12:meta versioni2e4:name1:a12:piece lengthi16384ee"
+	v2 := sha256.Sum256([]byte(v2Info))
+	tests := []struct {
+		name    string
+		torrent string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "v1 with announce-list and url-list",
+			torrent: "d8:announce8:http://a13:announce-listll8:http://a8:http://bel10:udp://c:80ee4:info" + testInfo + "8:url-list9:http://s/e",
+			want:    "magnet:?xt=" + btih + "&xl=3&dn=test.bin&tr.1=http%3A%2F%2Fa&tr.1=http%3A%2F%2Fb&tr.2=udp%3A%2F%2Fc%3A80&ws=http%3A%2F%2Fs%2F",
+		},
+		{
+			name:    "announce only",
+			torrent: "d8:announce21:http://a/announce?x=y4:info" + testInfo + "e",
+			want:    "magnet:?xt=" + btih + "&xl=3&dn=test.bin&tr=http%3A%2F%2Fa%2Fannounce%3Fx%3Dy",
+		},
+		{
+			name:    "v2",
+			torrent: "d4:info" + v2Info + "e",
+			want:    "magnet:?xt=urn:btmh:1220" + hex.EncodeToString(v2[:]) + "&dn=a",
+		},
+		{
+			name:    "no info",
+			torrent: "d8:announce8:http://ae",
+			wantErr: true,
+		},
+		{
+			name:    "not bencode",
+			torrent: "magnet:?",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromTorrent([]byte(tt.torrent))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromTorrent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.String() != tt.want {
+				t.Errorf("FromTorrent() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromTorrent_roundTrip(t *testing.T) {
+	m, err := FromTorrent([]byte("d13:announce-listll8:http://a8:http://bel10:udp://c:80ee4:info" + testInfo + "e"))
+	if err != nil {
+		t.Fatalf("FromTorrent() error = %v", err)
+	}
+	torrent, err := ToTorrentWith(m, []byte(testInfo), TorrentOptions{})
+	if err != nil {
+		t.Fatalf("ToTorrentWith() error = %v", err)
+	}
+	again, err := FromTorrent(torrent)
+	if err != nil || again.String() != m.String() {
+		t.Errorf("FromTorrent(ToTorrent()) = %v, %v, want %v", again, err, m)
+	}
+}
@@ -0,0 +1,225 @@
+package magneturi
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Tracker is a parsed tracker (tr) value.
+type Tracker struct {
+	// Index is the tr.N index the tracker had in the link, if any.
+	Index string
+	// Raw is the value as it appeared in the link.
+	Raw string
+	// URL is the decoded announce URL.
+	URL *url.URL
+	// Scheme is one of udp, http, https, ws or wss.
+	Scheme string
+	// Host is the lower case host name or IP address, without the
+	// port. Internationalized domain names are in punycode, see
+	// DisplayHost.
+	Host string
+	// Port is the explicit port or the default one of the scheme,
+	// zero for a udp tracker without a port.
+	Port int
+	// Path is the announce path, e.g. /announce.
+	Path string
+	// Passkey is the private tracker passkey found in the query
+	// string or path, if any.
+	Passkey string
+}
+
+var trackerSchemes = map[string]int{
+	"udp":   0,
+	"http":  80,
+	"https": 443,
+	"ws":    80,
+	"wss":   443,
+}
+
+var passkeyParams = []string{"passkey", "pk", "authkey", "key", "token"}
+
+// passkeyPath matches announce paths of the /<passkey>/announce form.
+var passkeyPath = regexp.MustCompile(`^/([0-9A-Za-z]{16,64})/announce`)
+
+// ParseTracker parses a tracker value as it appears in a link, which
+// may or may not be percent-encoded.
+func ParseTracker(raw string) (Tracker, error) {
+	value := raw
+	if !strings.Contains(value, "://") {
+		decoded, err := url.QueryUnescape(value)
+		if err != nil {
+			return Tracker{}, fmt.Errorf("invalid tracker encoding: %q", raw)
+		}
+		value = decoded
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return Tracker{}, fmt.Errorf("invalid tracker url: %q", raw)
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	defaultPort, ok := trackerSchemes[u.Scheme]
+	if !ok {
+		return Tracker{}, fmt.Errorf("unsupported tracker scheme: %q", raw)
+	}
+	if u.Hostname() == "" {
+		return Tracker{}, fmt.Errorf("tracker without host: %q", raw)
+	}
+	host, err := ParseHost(u.Hostname())
+	if err != nil {
+		return Tracker{}, fmt.Errorf("invalid tracker host: %q", raw)
+	}
+	t := Tracker{
+		Raw:    raw,
+		URL:    u,
+		Scheme: u.Scheme,
+		Host:   host.ASCII,
+		Port:   defaultPort,
+		Path:   u.Path,
+	}
+	if p := u.Port(); p != "" {
+		port, err := strconv.Atoi(p)
+		if err != nil || port <= 0 || port > 65535 {
+			return Tracker{}, fmt.Errorf("invalid tracker port: %q", raw)
+		}
+		t.Port = port
+	}
+	q := u.Query()
+	for _, k := range passkeyParams {
+		if v := q.Get(k); v != "" {
+			t.Passkey = v
+			break
+		}
+	}
+	if t.Passkey == "" {
+		if match := passkeyPath.FindStringSubmatch(u.Path); match != nil {
+			t.Passkey = match[1]
+		}
+	}
+	return t, nil
+}
+
+// String returns the decoded announce URL.
+func (t Tracker) String() string {
+	return t.URL.String()
+}
+
+// DisplayHost returns Host with internationalized domain names in
+// Unicode.
+func (t Tracker) DisplayHost() string {
+	if h, err := ParseHost(t.Host); err == nil {
+		return h.Unicode
+	}
+	return t.Host
+}
+
+// Key identifies the tracker for deduplication: scheme and host are
+// lower cased, default ports, trailing slashes and the order of query
+// parameters are ignored.
+func (t Tracker) Key() string {
+	host := t.Host
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if t.Port != trackerSchemes[t.Scheme] {
+		host += ":" + strconv.Itoa(t.Port)
+	}
+	key := t.Scheme + "://" + host + strings.TrimRight(t.Path, "/")
+	if len(t.URL.Query()) > 0 {
+		key += "?" + t.URL.Query().Encode()
+	}
+	return key
+}
+
+// IsPrivate reports whether the host is a loopback or private range
+// IP address (RFC 1918, RFC 4193) and so unreachable for most peers.
+func (t Tracker) IsPrivate() bool {
+	ip := net.ParseIP(t.Host)
+	return ip != nil && (ip.IsPrivate() || ip.IsLoopback() || ip.IsUnspecified())
+}
+
+var lanSuffixes = []string{".local", ".lan", ".home", ".internal", ".home.arpa", ".localdomain"}
+
+// IsLAN reports whether the host only resolves on a local network or
+// the machine itself: a loopback, link-local or private address,
+// localhost, a local domain or a single label name.
+func (t Tracker) IsLAN() bool {
+	if ip := net.ParseIP(t.Host); ip != nil {
+		return ip.IsLinkLocalUnicast() || ip.IsPrivate() || ip.IsLoopback() || ip.IsUnspecified()
+	}
+	if t.Host == "localhost" || !strings.Contains(t.Host, ".") {
+		return true
+	}
+	for _, suffix := range lanSuffixes {
+		if strings.HasSuffix(t.Host, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOnion reports whether the tracker is a Tor hidden service.
+func (t Tracker) IsOnion() bool {
+	return strings.HasSuffix(t.Host, ".onion")
+}
+
+// Trackers returns the parsed trackers of the link in order, skipping
+// values that do not parse and duplicates as defined by Tracker.Key.
+func (m *MagnetURI) Trackers() []Tracker {
+	var trackers []Tracker
+	seen := map[string]bool{}
+	for _, p := range m.params {
+		if p.prefix != "tr" {
+			continue
+		}
+		t, err := ParseTracker(p.value)
+		if err != nil || seen[t.Key()] {
+			continue
+		}
+		seen[t.Key()] = true
+		t.Index = p.index
+		trackers = append(trackers, t)
+	}
+	return trackers
+}
+
+// RemoveDuplicateTrackers drops every tr parameter that is a duplicate
+// of an earlier one and returns how many were removed. Values that do
+// not parse as a tracker are kept as they are.
+func (m *MagnetURI) RemoveDuplicateTrackers() int {
+	seen := map[string]bool{}
+	kept := m.params[:0]
+	for _, p := range m.params {
+		if p.prefix == "tr" {
+			if t, err := ParseTracker(p.value); err == nil {
+				if seen[t.Key()] {
+					continue
+				}
+				seen[t.Key()] = true
+			}
+		}
+		kept = append(kept, p)
+	}
+	removed := len(m.params) - len(kept)
+	m.params = kept
+	return removed
+}
+
+// TrackerHosts returns the distinct tracker hosts, sorted.
+func (m *MagnetURI) TrackerHosts() []string {
+	var hosts []string
+	seen := map[string]bool{}
+	for _, t := range m.Trackers() {
+		if !seen[t.Host] {
+			seen[t.Host] = true
+			hosts = append(hosts, t.Host)
+		}
+	}
+	sort.Strings(hosts)
+	return hosts
+}
@@ -0,0 +1,152 @@
+package magneturi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTracker(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    Tracker
+		wantErr bool
+	}{
+		{
+			name: "encoded udp",
+			raw:  "udp%3A%2F%2Ftracker.openbittorrent.com%3A80%2Fannounce",
+			want: Tracker{Scheme: "udp", Host: "tracker.openbittorrent.com", Port: 80, Path: "/announce"},
+		},
+		{
+			name: "http default port with passkey",
+			raw:  "http://Tracker.Example.org/announce.php?passkey=0123456789abcdef",
+			want: Tracker{Scheme: "http", Host: "tracker.example.org", Port: 80, Path: "/announce.php", Passkey: "0123456789abcdef"},
+		},
+		{
+			name: "https passkey in path",
+			raw:  "https://tracker.example.org:8443/0123456789abcdef0123456789abcdef/announce",
+			want: Tracker{Scheme: "https", Host: "tracker.example.org", Port: 8443, Path: "/0123456789abcdef0123456789abcdef/announce", Passkey: "0123456789abcdef0123456789abcdef"},
+		},
+		{
+			name: "wss",
+			raw:  "wss://tracker.webtorrent.dev",
+			want: Tracker{Scheme: "wss", Host: "tracker.webtorrent.dev", Port: 443},
+		},
+		{
+			name:    "unsupported scheme",
+			raw:     "dchub://example.org",
+			wantErr: true,
+		},
+		{
+			name:    "no host",
+			raw:     "udp:///announce",
+			wantErr: true,
+		},
+		{
+			name:    "bad port",
+			raw:     "udp://example.org:99999/announce",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTracker(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTracker() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got.Raw, got.URL = "", nil
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseTracker() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTracker_Key(t *testing.T) {
+	same := []string{
+		"http://tracker.example.org/announce",
+		"http%3A%2F%2Ftracker.example.org%2Fannounce",
+		"HTTP://TRACKER.example.org:80/announce/",
+	}
+	for _, raw := range same {
+		tr, err := ParseTracker(raw)
+		if err != nil {
+			t.Fatalf("ParseTracker(%q) error = %v", raw, err)
+		}
+		if got, want := tr.Key(), "http://tracker.example.org/announce"; got != want {
+			t.Errorf("Tracker.Key() of %q = %v, want %v", raw, got, want)
+		}
+	}
+	tr, _ := ParseTracker("udp://[::1]:6969/announce?b=2&a=1")
+	if got, want := tr.Key(), "udp://[::1]:6969/announce?a=1&b=2"; got != want {
+		t.Errorf("Tracker.Key() = %v, want %v", got, want)
+	}
+}
+
+func TestTracker_classification(t *testing.T) {
+	tests := []struct {
+		raw         string
+		wantPrivate bool
+		wantLAN     bool
+		wantOnion   bool
+	}{
+		{"udp://tracker.example.org:80", false, false, false},
+		{"http://192.168.1.10:6969/announce", true, true, false},
+		{"http://127.0.0.1/announce", true, true, false},
+		{"http://localhost/announce", false, true, false},
+		{"http://[::1]:6969/announce", true, true, false},
+		{"http://0.0.0.0:6969/announce", true, true, false},
+		{"http://[fe80::1]:6969/announce", false, true, false},
+		{"http://nas.local:6969/announce", false, true, false},
+		{"http://tracker/announce", false, true, false},
+		{"http://abcdefghijklmnop.onion/announce", false, false, true},
+	}
+	for _, tt := range tests {
+		tr, err := ParseTracker(tt.raw)
+		if err != nil {
+			t.Fatalf("ParseTracker(%q) error = %v", tt.raw, err)
+		}
+		if got := tr.IsPrivate(); got != tt.wantPrivate {
+			t.Errorf("Tracker.IsPrivate() of %q = %v, want %v", tt.raw, got, tt.wantPrivate)
+		}
+		if got := tr.IsLAN(); got != tt.wantLAN {
+			t.Errorf("Tracker.IsLAN() of %q = %v, want %v", tt.raw, got, tt.wantLAN)
+		}
+		if got := tr.IsOnion(); got != tt.wantOnion {
+			t.Errorf("Tracker.IsOnion() of %q = %v, want %v", tt.raw, got, tt.wantOnion)
+		}
+	}
+}
+
+func TestMagnetURI_Trackers(t *testing.T) {
+	m := &MagnetURI{
+		params: []param{
+			param{"xt", "", "urn:btih:QHQXPYWMACKDWKP47RRVIV7VOURXFE5Q"},
+			param{"tr", "1", "udp%3A%2F%2Ftracker.openbittorrent.com%3A80%2Fannounce"},
+			param{"tr", "2", "udp://tracker.openbittorrent.com:80/announce/"},
+			param{"tr", "", "dchub://example.org"},
+			param{"tr", "", "http://a.example.org/announce"},
+		},
+	}
+	var keys []string
+	for _, tr := range m.Trackers() {
+		keys = append(keys, tr.Index+" "+tr.Key())
+	}
+	want := []string{"1 udp://tracker.openbittorrent.com:80/announce", " http://a.example.org/announce"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("MagnetURI.Trackers() = %q, want %q", keys, want)
+	}
+	if got, want := m.TrackerHosts(), []string{"a.example.org", "tracker.openbittorrent.com"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MagnetURI.TrackerHosts() = %q, want %q", got, want)
+	}
+	if got := m.RemoveDuplicateTrackers(); got != 1 {
+		t.Errorf("MagnetURI.RemoveDuplicateTrackers() = %v, want 1", got)
+	}
+	want2 := "magnet:?xt=urn:btih:QHQXPYWMACKDWKP47RRVIV7VOURXFE5Q&tr.1=udp%3A%2F%2Ftracker.openbittorrent.com%3A80%2Fannounce&tr=dchub://example.org&tr=http://a.example.org/announce"
+	if got := m.String(); got != want2 {
+		t.Errorf("MagnetURI.String() = %v, want %v", got, want2)
+	}
+}
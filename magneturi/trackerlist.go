@@ -0,0 +1,180 @@
+package magneturi
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// AddTrackersOptions control how AddTrackersFromWith adds the trackers
+// of a list to a link.
+type AddTrackersOptions struct {
+	// MaxTrackers caps the number of distinct trackers of the link,
+	// 0 means no limit. Trackers the link already has are kept even
+	// above the cap.
+	MaxTrackers int
+	// MinScore skips trackers with a health score below it. Trackers
+	// without a score are always added.
+	MinScore float64
+}
+
+// DefaultAddTrackersOptions are what AddTrackersFrom uses.
+var DefaultAddTrackersOptions = AddTrackersOptions{}
+
+// listedTracker is a tracker of a list with its optional score.
+type listedTracker struct {
+	tracker Tracker
+	score   float64
+	scored  bool
+	// order is the position in the list, the tie breaker when ranking
+	// by score.
+	order int
+}
+
+// AddTrackersFrom is AddTrackersFromWith using
+// DefaultAddTrackersOptions.
+func (m *MagnetURI) AddTrackersFrom(r io.Reader) (int, error) {
+	return m.AddTrackersFromWith(r, DefaultAddTrackersOptions)
+}
+
+// AddTrackersFromWith adds the trackers of a tracker list read from r
+// that the link does not have yet as defined by Tracker.Key, and
+// returns how many it added. The list has one announce URL per line,
+// blank lines separate tiers and lines starting with # are comments.
+// An optional health score may follow the URL after white space, e.g.
+//
+//	udp://tracker.example.org:1337/announce 0.98
+//
+// When the cap leaves room for only some of the trackers the ones with
+// the highest score win, trackers without a score count as 0 and ties
+// go to the one listed first. Trackers are appended after the link's
+// parameters as tr parameters when each tier holds a single tracker.
+// Otherwise they are appended as tr.N tiers that AnnounceList places
+// after the link's own trackers: the link's unindexed trackers, which
+// would sort after any tier, are numbered in link order after its
+// highest tr index and the new tiers after them. Other parameters,
+// including tr values that do not parse, are left as they are. The
+// link is left unchanged if the list does not parse.
+func (m *MagnetURI) AddTrackersFromWith(r io.Reader, opts AddTrackersOptions) (int, error) {
+	tiers, err := readTrackerList(r)
+	if err != nil {
+		return 0, err
+	}
+	existing := m.Trackers()
+	seen := map[string]bool{}
+	for _, t := range existing {
+		seen[t.Key()] = true
+	}
+	var candidates []listedTracker
+	for _, tier := range tiers {
+		for _, lt := range tier {
+			if seen[lt.tracker.Key()] || (lt.scored && lt.score < opts.MinScore) {
+				continue
+			}
+			seen[lt.tracker.Key()] = true
+			candidates = append(candidates, lt)
+		}
+	}
+	if room := opts.MaxTrackers - len(existing); opts.MaxTrackers > 0 && len(candidates) > room {
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].score > candidates[j].score
+		})
+		candidates = candidates[:max(room, 0)]
+	}
+	keep := map[int]bool{}
+	for _, lt := range candidates {
+		keep[lt.order] = true
+	}
+	var added AnnounceList
+	flat := true
+	for _, tier := range tiers {
+		var kept []Tracker
+		for _, lt := range tier {
+			if keep[lt.order] {
+				kept = append(kept, lt.tracker)
+			}
+		}
+		if len(kept) > 0 {
+			added = append(added, kept)
+			flat = flat && len(kept) == 1
+		}
+	}
+	if flat {
+		for _, tier := range added {
+			m.params = append(m.params, param{"tr", "", tier[0].Raw})
+		}
+	} else {
+		next := 1
+		for _, p := range m.params {
+			if n, err := strconv.Atoi(p.index); err == nil && p.prefix == "tr" && n >= next {
+				next = n + 1
+			}
+		}
+		for i, p := range m.params {
+			if _, err := strconv.Atoi(p.index); err == nil || p.prefix != "tr" {
+				continue
+			}
+			if _, err := ParseTracker(p.value); err == nil {
+				m.params[i].index = strconv.Itoa(next)
+				next++
+			}
+		}
+		for i, tier := range added {
+			for _, t := range tier {
+				m.params = append(m.params, param{"tr", strconv.Itoa(next + i), t.Raw})
+			}
+		}
+	}
+	return len(candidates), nil
+}
+
+// readTrackerList reads a tracker list into tiers, see
+// AddTrackersFromWith for the format.
+func readTrackerList(r io.Reader) ([][]listedTracker, error) {
+	var tiers [][]listedTracker
+	var tier []listedTracker
+	order := 0
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			if len(tier) > 0 {
+				tiers = append(tiers, tier)
+				tier = nil
+			}
+			continue
+		}
+		if strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("tracker list line %d: unexpected text after the score: %q", line, scanner.Text())
+		}
+		t, err := ParseTracker(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("tracker list line %d: %v", line, err)
+		}
+		t.Raw = url.QueryEscape(t.String())
+		lt := listedTracker{tracker: t, order: order}
+		if len(fields) == 2 {
+			lt.score, err = strconv.ParseFloat(fields[1], 64)
+			if err != nil {
+				return nil, fmt.Errorf("tracker list line %d: invalid score: %q", line, fields[1])
+			}
+			lt.scored = true
+		}
+		tier = append(tier, lt)
+		order++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(tier) > 0 {
+		tiers = append(tiers, tier)
+	}
+	return tiers, nil
+}
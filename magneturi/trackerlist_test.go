@@ -0,0 +1,126 @@
+package magneturi
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const trackerList = `# best public trackers
+udp://tracker.opentrackr.org:1337/announce
+
+udp://open.stealth.si:80/announce
+
+UDP://Tracker.Example.org:6969/announce/
+`
+
+func TestAddTrackersFrom(t *testing.T) {
+	tests := []struct {
+		name      string
+		link      string
+		list      string
+		opts      AddTrackersOptions
+		want      string
+		wantTiers [][]string
+		wantAdded int
+		wantErr   bool
+	}{
+		{
+			name:      "flat list",
+			link:      "magnet:?xt=urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a",
+			list:      trackerList,
+			want:      "magnet:?xt=urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a&tr=udp%3A%2F%2Ftracker.opentrackr.org%3A1337%2Fannounce&tr=udp%3A%2F%2Fopen.stealth.si%3A80%2Fannounce&tr=udp%3A%2F%2FTracker.Example.org%3A6969%2Fannounce%2F",
+			wantAdded: 3,
+		},
+		{
+			name:      "duplicates of existing trackers",
+			link:      "magnet:?dn=a&tr=udp://tracker.example.org:6969/announce&tr=udp%3A%2F%2Fopen.stealth.si%3A80%2Fannounce",
+			list:      trackerList + "\nudp://tracker.opentrackr.org:1337/announce\n",
+			want:      "magnet:?dn=a&tr=udp://tracker.example.org:6969/announce&tr=udp%3A%2F%2Fopen.stealth.si%3A80%2Fannounce&tr=udp%3A%2F%2Ftracker.opentrackr.org%3A1337%2Fannounce",
+			wantAdded: 1,
+		},
+		{
+			name: "tiers after the link's trackers",
+			link: "magnet:?dn=a&tr=http://private.example/announce",
+			list: "udp://b:1/announce\nudp://c:1/announce\n\n\nudp://d:1/announce\n",
+			want: "magnet:?dn=a&tr.1=http://private.example/announce&tr.2=udp%3A%2F%2Fb%3A1%2Fannounce&tr.2=udp%3A%2F%2Fc%3A1%2Fannounce" +
+				"&tr.3=udp%3A%2F%2Fd%3A1%2Fannounce",
+			wantTiers: [][]string{{"http://private.example/announce"}, {"udp://b:1/announce", "udp://c:1/announce"}, {"udp://d:1/announce"}},
+			wantAdded: 3,
+		},
+		{
+			name:      "tiers after indexed, unindexed and unparseable trackers",
+			link:      "magnet:?tr=not-a-url&tr.5=http://a/announce&tr=http://e/announce&tr.2=http://f/announce",
+			list:      "udp://b:1\nudp://c:1\n",
+			want:      "magnet:?tr=not-a-url&tr.5=http://a/announce&tr.6=http://e/announce&tr.2=http://f/announce&tr.7=udp%3A%2F%2Fb%3A1&tr.7=udp%3A%2F%2Fc%3A1",
+			wantTiers: [][]string{{"http://f/announce"}, {"http://a/announce"}, {"http://e/announce"}, {"udp://b:1", "udp://c:1"}},
+			wantAdded: 2,
+		},
+		{
+			name:      "cap keeps the best scores",
+			link:      "magnet:?dn=a&tr=http://a/announce",
+			list:      "udp://b:1 0.5\n\nudp://c:1 0.9\n\nudp://d:1\n\nudp://e:1 0.9\n",
+			opts:      AddTrackersOptions{MaxTrackers: 3},
+			want:      "magnet:?dn=a&tr=http://a/announce&tr=udp%3A%2F%2Fc%3A1&tr=udp%3A%2F%2Fe%3A1",
+			wantAdded: 2,
+		},
+		{
+			name:      "cap already reached",
+			link:      "magnet:?dn=a&tr=http://a/announce&tr=http://b/announce",
+			list:      "udp://c:1\n",
+			opts:      AddTrackersOptions{MaxTrackers: 1},
+			want:      "magnet:?dn=a&tr=http://a/announce&tr=http://b/announce",
+			wantAdded: 0,
+		},
+		{
+			name:      "min score",
+			link:      "magnet:?dn=a",
+			list:      "udp://b:1 0.5\nudp://c:1 0.95\nudp://d:1\n",
+			opts:      AddTrackersOptions{MinScore: 0.9},
+			want:      "magnet:?dn=a&tr.1=udp%3A%2F%2Fc%3A1&tr.1=udp%3A%2F%2Fd%3A1",
+			wantAdded: 2,
+		},
+		{
+			name:    "invalid tracker",
+			link:    "magnet:?dn=a",
+			list:    "udp://b:1\nftp://c/\n",
+			want:    "magnet:?dn=a",
+			wantErr: true,
+		},
+		{
+			name:    "invalid score",
+			link:    "magnet:?dn=a",
+			list:    "udp://b:1 high\n",
+			want:    "magnet:?dn=a",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := MustParse(tt.link)
+			added, err := m.AddTrackersFromWith(strings.NewReader(tt.list), tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AddTrackersFromWith() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if added != tt.wantAdded {
+				t.Errorf("AddTrackersFromWith() = %d, want %d", added, tt.wantAdded)
+			}
+			if got := m.String(); got != tt.want {
+				t.Errorf("link = %s, want %s", got, tt.want)
+			}
+			if tt.wantTiers != nil {
+				var tiers [][]string
+				for _, tier := range m.AnnounceList() {
+					var keys []string
+					for _, tr := range tier {
+						keys = append(keys, tr.Key())
+					}
+					tiers = append(tiers, keys)
+				}
+				if !reflect.DeepEqual(tiers, tt.wantTiers) {
+					t.Errorf("AnnounceList() = %q, want %q", tiers, tt.wantTiers)
+				}
+			}
+		})
+	}
+}
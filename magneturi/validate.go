@@ -0,0 +1,126 @@
+package magneturi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Profile is a set of semantic rules a MagnetURI must satisfy to be
+// usable on a particular network.
+type Profile int
+
+// The built-in validation profiles.
+const (
+	// Generic needs an exact topic (xt) or else a keyword topic (kt)
+	// or manifest topic (mt).
+	Generic Profile = iota
+	// BitTorrent needs a btih or btmh exact topic.
+	BitTorrent
+	// EDonkey needs an ed2k exact topic, an exact length (xl) and a
+	// display name (dn).
+	EDonkey
+	// Gnutella needs a sha1 or bitprint exact topic.
+	Gnutella
+)
+
+var profileNames = map[Profile]string{
+	Generic:    "generic",
+	BitTorrent: "BitTorrent",
+	EDonkey:    "eD2k",
+	Gnutella:   "Gnutella",
+}
+
+func (p Profile) String() string {
+	if name, ok := profileNames[p]; ok {
+		return name
+	}
+	return "Profile(" + strconv.Itoa(int(p)) + ")"
+}
+
+// ValidationError lists every rule of a Profile the MagnetURI broke.
+type ValidationError struct {
+	Profile    Profile
+	Violations []string
+}
+
+func (e *ValidationError) Error() string {
+	return "magnet uri is not valid for the " + e.Profile.String() + " profile: " +
+		strings.Join(e.Violations, "; ")
+}
+
+// Validate checks the MagnetURI against the given profiles, with no
+// profile the Generic rules are used. All violations of the first
+// failing profile are returned at once as a *ValidationError.
+func (m *MagnetURI) Validate(profiles ...Profile) error {
+	if len(profiles) == 0 {
+		profiles = []Profile{Generic}
+	}
+	for _, profile := range profiles {
+		if violations := m.violations(profile); len(violations) > 0 {
+			return &ValidationError{profile, violations}
+		}
+	}
+	return nil
+}
+
+func (m *MagnetURI) violations(profile Profile) []string {
+	var v []string
+	if len(m.params) == 0 {
+		v = append(v, "the Magnet URI has no parameters")
+	}
+	for _, t := range m.ExactTopics() {
+		if _, known := hashFormats[t.Namespace]; known && !t.ValidHash() {
+			v = append(v, "exact topic "+strconv.Quote(t.String())+" has a malformed "+t.Namespace+" hash")
+		}
+	}
+	switch profile {
+	case Generic:
+		if !m.HasPrefix("xt") && !m.HasPrefix("kt") && !m.HasPrefix("mt") {
+			v = append(v, "an exact topic (xt), keyword topic (kt) or manifest topic (mt) is required")
+		}
+	case BitTorrent:
+		if !m.hasTopic("btih", "btmh") {
+			v = append(v, "a BitTorrent info-hash exact topic (urn:btih or urn:btmh) is required")
+		}
+	case EDonkey:
+		if !m.hasTopic("ed2k") {
+			v = append(v, "an eD2k hash exact topic (urn:ed2k) is required")
+		}
+		if xl, ok := m.firstValue("xl"); !ok {
+			v = append(v, "an exact length (xl) is required")
+		} else if n, err := strconv.ParseInt(xl, 10, 64); err != nil || n <= 0 {
+			v = append(v, "the exact length (xl) "+strconv.Quote(xl)+" is not a positive number")
+		}
+		if _, ok := m.firstValue("dn"); !ok {
+			v = append(v, "a display name (dn) is required")
+		}
+	case Gnutella:
+		if !m.hasTopic("sha1", "bitprint") {
+			v = append(v, "a SHA-1 or bitprint exact topic (urn:sha1 or urn:bitprint) is required")
+		}
+	default:
+		v = append(v, "unknown profile "+profile.String())
+	}
+	return v
+}
+
+func (m *MagnetURI) firstValue(prefix string) (string, bool) {
+	for _, p := range m.params {
+		if p.prefix == prefix && p.value != "" {
+			return p.value, true
+		}
+	}
+	return "", false
+}
+
+// ParseProfile returns the profile with the given name, as returned by
+// Profile.String, ignoring case.
+func ParseProfile(name string) (Profile, error) {
+	for p, n := range profileNames {
+		if strings.EqualFold(n, name) {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown validation profile: %q", name)
+}
@@ -0,0 +1,175 @@
+package magneturi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMagnetURI_Validate(t *testing.T) {
+	tests := []struct {
+		name           string
+		m              MagnetURI
+		profiles       []Profile
+		wantViolations []string
+	}{
+		{
+			name:     "Generic xt",
+			m:        MagnetURI{params: []param{param{"xt", "", "urn:btih:QHQXPYWMACKDWKP47RRVIV7VOURXFE5Q"}}},
+			profiles: nil,
+		},
+		{
+			name:     "Generic kt",
+			m:        MagnetURI{params: []param{param{"kt", "", "martin+luther+king+mp3"}}},
+			profiles: []Profile{Generic},
+		},
+		{
+			name:     "Generic mt",
+			m:        MagnetURI{params: []param{param{"mt", "", "http://weblog.foo/all-my-favorites.rss"}}},
+			profiles: nil,
+		},
+		{
+			name:           "Generic dn only",
+			m:              MagnetURI{params: []param{param{"dn", "", "mediawiki-1.15.1.tar.gz"}}},
+			profiles:       nil,
+			wantViolations: []string{"an exact topic (xt), keyword topic (kt) or manifest topic (mt) is required"},
+		},
+		{
+			name:     "Generic no params",
+			m:        MagnetURI{},
+			profiles: nil,
+			wantViolations: []string{
+				"the Magnet URI has no parameters",
+				"an exact topic (xt), keyword topic (kt) or manifest topic (mt) is required",
+			},
+		},
+		{
+			name: "BitTorrent btih",
+			m: MagnetURI{params: []param{
+				param{"xt", "", "urn:btih:c12fe1c06bba254a9dc9f519b335aa7c1367a88a"},
+			}},
+			profiles: []Profile{BitTorrent},
+		},
+		{
+			name: "BitTorrent btmh",
+			m: MagnetURI{params: []param{
+				param{"xt", "", "urn:btmh:1220caf1e1c30e81cb361b9ee167c4aa64228a7fa4fa9f6105232b28ad099f3a302e"},
+			}},
+			profiles: []Profile{BitTorrent},
+		},
+		{
+			name: "BitTorrent malformed btih",
+			m: MagnetURI{params: []param{
+				param{"xt", "", "urn:btih:c12fe1c06bba"},
+			}},
+			profiles: []Profile{BitTorrent},
+			wantViolations: []string{
+				"exact topic \"urn:btih:c12fe1c06bba\" has a malformed btih hash",
+				"a BitTorrent info-hash exact topic (urn:btih or urn:btmh) is required",
+			},
+		},
+		{
+			name: "BitTorrent ed2k only",
+			m: MagnetURI{params: []param{
+				param{"xt", "", "urn:ed2k:354B15E68FB8F36D7CD88FF94116CDC1"},
+				param{"dn", "", "mediawiki-1.15.1.tar.gz"},
+			}},
+			profiles:       []Profile{BitTorrent},
+			wantViolations: []string{"a BitTorrent info-hash exact topic (urn:btih or urn:btmh) is required"},
+		},
+		{
+			name: "eD2k complete",
+			m: MagnetURI{params: []param{
+				param{"xt", "", "urn:ed2k:354B15E68FB8F36D7CD88FF94116CDC1"},
+				param{"xl", "", "10826029"},
+				param{"dn", "", "mediawiki-1.15.1.tar.gz"},
+			}},
+			profiles: []Profile{EDonkey},
+		},
+		{
+			name: "eD2k all violations",
+			m: MagnetURI{params: []param{
+				param{"xt", "", "urn:btih:QHQXPYWMACKDWKP47RRVIV7VOURXFE5Q"},
+				param{"xl", "", "big"},
+			}},
+			profiles: []Profile{EDonkey},
+			wantViolations: []string{
+				"an eD2k hash exact topic (urn:ed2k) is required",
+				"the exact length (xl) \"big\" is not a positive number",
+				"a display name (dn) is required",
+			},
+		},
+		{
+			name: "Gnutella sha1",
+			m: MagnetURI{params: []param{
+				param{"xt", "", "urn:sha1:QLFYWY2RI5WZCTEP6MJKR5CAFGP7FQ5X"},
+			}},
+			profiles: []Profile{Gnutella},
+		},
+		{
+			name: "Gnutella bitprint",
+			m: MagnetURI{params: []param{
+				param{"xt", "", "urn:bitprint:QLFYWY2RI5WZCTEP6MJKR5CAFGP7FQ5X.VEKXTRSJPTZJLY2IKG5FQ2TCXK26SECFPP4DX7I"},
+			}},
+			profiles: []Profile{Gnutella},
+		},
+		{
+			name: "Gnutella and BitTorrent stops at first failing profile",
+			m: MagnetURI{params: []param{
+				param{"xt", "", "urn:sha1:QLFYWY2RI5WZCTEP6MJKR5CAFGP7FQ5X"},
+			}},
+			profiles:       []Profile{Gnutella, BitTorrent},
+			wantViolations: []string{"a BitTorrent info-hash exact topic (urn:btih or urn:btmh) is required"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.m.Validate(tt.profiles...)
+			if tt.wantViolations == nil {
+				if err != nil {
+					t.Errorf("MagnetURI.Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			verr, ok := err.(*ValidationError)
+			if !ok {
+				t.Fatalf("MagnetURI.Validate() error = %#v, want *ValidationError", err)
+			}
+			if !reflect.DeepEqual(verr.Violations, tt.wantViolations) {
+				t.Errorf("MagnetURI.Validate() violations = %q, want %q", verr.Violations, tt.wantViolations)
+			}
+		})
+	}
+}
+
+func TestValidationError_Error(t *testing.T) {
+	err := &ValidationError{EDonkey, []string{"a", "b"}}
+	want := "magnet uri is not valid for the eD2k profile: a; b"
+	if got := err.Error(); got != want {
+		t.Errorf("ValidationError.Error() = %v, want %v", got, want)
+	}
+}
+
+func TestParseProfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Profile
+		wantErr bool
+	}{
+		{"generic", Generic, false},
+		{"bittorrent", BitTorrent, false},
+		{"eD2k", EDonkey, false},
+		{"Gnutella", Gnutella, false},
+		{"kazaa", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseProfile(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseProfile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseProfile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package magneturi
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+)
+
+// VerboseFormat selects the layout used by WriteVerbose.
+type VerboseFormat int
+
+// The formats supported by WriteVerbose.
+const (
+	// FormatTable is the aligned plain text table PrintVerbose prints.
+	FormatTable VerboseFormat = iota
+	// FormatMarkdown is a GitHub flavoured Markdown table.
+	FormatMarkdown
+	// FormatCSV is RFC 4180 CSV with a header row.
+	FormatCSV
+	// FormatKeyValue is one aligned "key: value" line per parameter.
+	FormatKeyValue
+)
+
+// VerboseOptions configure WriteVerbose, the zero value writes a table
+// with the raw values.
+type VerboseOptions struct {
+	Format VerboseFormat
+	// Decode percent-decodes values before they are written.
+	Decode bool
+	// MaxValueLen truncates values longer than this many characters,
+	// zero means no limit.
+	MaxValueLen int
+}
+
+var verboseHeader = []string{"#", "Prefix", "Index/Exp", "Description", "Value"}
+
+// WriteVerbose writes the parameters of the MagnetURI to w in the
+// format selected by opts.
+func (m *MagnetURI) WriteVerbose(w io.Writer, opts VerboseOptions) error {
+	rows := make([][]string, 0, len(m.params))
+	for i, p := range m.params {
+		rows = append(rows, []string{strconv.Itoa(i), p.prefix, p.index, paramType()[p.prefix], opts.value(p.value)})
+	}
+	switch opts.Format {
+	case FormatTable:
+		tw := tabwriter.NewWriter(w, 0, 1, 1, ' ', tabwriter.TabIndent)
+		fmt.Fprintln(tw, strings.Join(verboseHeader, "\t"))
+		fmt.Fprintln(tw, "=\t======\t=========\t===========\t=====")
+		for _, row := range rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	case FormatMarkdown:
+		lines := []string{
+			"| " + strings.Join(verboseHeader, " | ") + " |",
+			"|---|---|---|---|---|",
+		}
+		for _, row := range rows {
+			cells := make([]string, len(row))
+			for i, cell := range row {
+				cells[i] = strings.NewReplacer("|", `\|`, "\n", " ").Replace(cell)
+			}
+			lines = append(lines, "| "+strings.Join(cells, " | ")+" |")
+		}
+		_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+		return err
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		cw.Write(verboseHeader)
+		cw.WriteAll(rows)
+		return cw.Error()
+	case FormatKeyValue:
+		tw := tabwriter.NewWriter(w, 0, 1, 1, ' ', 0)
+		for i, p := range m.params {
+			fmt.Fprintf(tw, "%s:\t%s\n", paramKey(p), rows[i][4])
+		}
+		return tw.Flush()
+	}
+	return fmt.Errorf("unknown verbose format: %d", opts.Format)
+}
+
+// paramKey is the parameter name as it appears in the URI, e.g. xt.1.
+func paramKey(p param) string {
+	if p.index != "" {
+		return strings.TrimRight(p.prefix, ".") + "." + p.index
+	}
+	return p.prefix
+}
+
+func (opts VerboseOptions) value(v string) string {
+	if opts.Decode {
+		if decoded, err := url.QueryUnescape(v); err == nil {
+			v = decoded
+		}
+	}
+	if opts.MaxValueLen > 0 && utf8.RuneCountInString(v) > opts.MaxValueLen {
+		v = string([]rune(v)[:opts.MaxValueLen-1]) + "…"
+	}
+	return v
+}
@@ -0,0 +1,78 @@
+package magneturi
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMagnetURI_WriteVerbose(t *testing.T) {
+	m := &MagnetURI{
+		params: []param{
+			param{"xt", "1", "urn:ed2k:354B15E68FB8F36D7CD88FF94116CDC1"},
+			param{"dn", "", "media|wiki+1.15.1.tar.gz"},
+			param{"tr", "", "udp%3A%2F%2Ftracker.openbittorrent.com%3A80%2Fannounce"},
+			param{"x.", "Moz11", "test"},
+		},
+	}
+	tests := []struct {
+		name string
+		opts VerboseOptions
+		want string
+	}{
+		{
+			name: "table",
+			opts: VerboseOptions{},
+			want: "# Prefix Index/Exp Description  Value\n" +
+				"= ====== ========= ===========  =====\n" +
+				"0 xt     1         exactTopic   urn:ed2k:354B15E68FB8F36D7CD88FF94116CDC1\n" +
+				"1 dn               displayName  media|wiki+1.15.1.tar.gz\n" +
+				"2 tr               tracker      udp%3A%2F%2Ftracker.openbittorrent.com%3A80%2Fannounce\n" +
+				"3 x.     Moz11     experimental test\n",
+		},
+		{
+			name: "markdown decoded",
+			opts: VerboseOptions{Format: FormatMarkdown, Decode: true},
+			want: "| # | Prefix | Index/Exp | Description | Value |\n" +
+				"|---|---|---|---|---|\n" +
+				"| 0 | xt | 1 | exactTopic | urn:ed2k:354B15E68FB8F36D7CD88FF94116CDC1 |\n" +
+				"| 1 | dn |  | displayName | media\\|wiki 1.15.1.tar.gz |\n" +
+				"| 2 | tr |  | tracker | udp://tracker.openbittorrent.com:80/announce |\n" +
+				"| 3 | x. | Moz11 | experimental | test |\n",
+		},
+		{
+			name: "csv truncated",
+			opts: VerboseOptions{Format: FormatCSV, MaxValueLen: 10},
+			want: "#,Prefix,Index/Exp,Description,Value\n" +
+				"0,xt,1,exactTopic,urn:ed2k:…\n" +
+				"1,dn,,displayName,media|wik…\n" +
+				"2,tr,,tracker,udp%3A%2F…\n" +
+				"3,x.,Moz11,experimental,test\n",
+		},
+		{
+			name: "key value",
+			opts: VerboseOptions{Format: FormatKeyValue, Decode: true},
+			want: "xt.1:    urn:ed2k:354B15E68FB8F36D7CD88FF94116CDC1\n" +
+				"dn:      media|wiki 1.15.1.tar.gz\n" +
+				"tr:      udp://tracker.openbittorrent.com:80/announce\n" +
+				"x.Moz11: test\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := m.WriteVerbose(&buf, tt.opts); err != nil {
+				t.Fatalf("MagnetURI.WriteVerbose() error = %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("MagnetURI.WriteVerbose() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMagnetURI_WriteVerboseUnknownFormat(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&MagnetURI{}).WriteVerbose(&buf, VerboseOptions{Format: 42}); err == nil {
+		t.Errorf("MagnetURI.WriteVerbose() error = nil, want error")
+	}
+}
@@ -0,0 +1,156 @@
+package magneturi
+
+import (
+	"bytes"
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// VerifyStatus is the outcome of checking one exact topic.
+type VerifyStatus int
+
+// The possible outcomes of checking an exact topic.
+const (
+	// Matched means the content hashes to the topic.
+	Matched VerifyStatus = iota
+	// Mismatched means the content hashes to something else.
+	Mismatched
+	// Unsupported means the topic could not be checked, either the
+	// algorithm is unknown or the hash does not follow from the
+	// content alone.
+	Unsupported
+)
+
+func (s VerifyStatus) String() string {
+	switch s {
+	case Matched:
+		return "matched"
+	case Mismatched:
+		return "mismatched"
+	case Unsupported:
+		return "unsupported"
+	}
+	return "VerifyStatus(" + strconv.Itoa(int(s)) + ")"
+}
+
+// TopicResult is the outcome of checking a single exact topic, Got is
+// the hash computed from the content when there is one.
+type TopicResult struct {
+	Topic  ExactTopic
+	Status VerifyStatus
+	Got    string
+}
+
+// VerifyReport is what Verify found. ExpectedLength is -1 when the
+// link has no exact length (xl).
+type VerifyReport struct {
+	Length         int64
+	ExpectedLength int64
+	Topics         []TopicResult
+}
+
+// LengthMatched reports whether the content length equals the exact
+// length of the link, links without one always match.
+func (r VerifyReport) LengthMatched() bool {
+	return r.ExpectedLength < 0 || r.ExpectedLength == r.Length
+}
+
+// OK reports whether the length and at least one exact topic matched
+// and no topic mismatched.
+func (r VerifyReport) OK() bool {
+	return r.LengthMatched() && len(r.ByStatus(Matched)) > 0 && len(r.ByStatus(Mismatched)) == 0
+}
+
+// ByStatus returns the topics that ended with the given status.
+func (r VerifyReport) ByStatus(status VerifyStatus) []ExactTopic {
+	var topics []ExactTopic
+	for _, t := range r.Topics {
+		if t.Status == status {
+			topics = append(topics, t.Topic)
+		}
+	}
+	return topics
+}
+
+// Verify reads r once and checks its content against every exact
+// topic and the exact length of m.
+//
+// BitTorrent info-hashes cover the torrent metadata as well as the
+// content, they can only match when the torrent was made the way
+// FromReader makes one and are reported as Unsupported otherwise.
+func Verify(m *MagnetURI, r io.Reader) (VerifyReport, error) {
+	report := VerifyReport{ExpectedLength: -1}
+	if xl, ok := m.firstValue("xl"); ok {
+		n, err := strconv.ParseInt(xl, 10, 64)
+		if err != nil || n < 0 {
+			return report, fmt.Errorf("invalid exact length: %q", xl)
+		}
+		report.ExpectedLength = n
+	}
+	name, _ := m.firstValue("dn")
+	if decoded, err := url.QueryUnescape(name); err == nil {
+		name = decoded
+	}
+
+	hashes := map[string]contentHash{}
+	var writers []io.Writer
+	topics := m.ExactTopics()
+	for _, t := range topics {
+		if _, ok := hashes[t.Namespace]; ok {
+			continue
+		}
+		h, err := newContentHash(Algorithm(t.Namespace), name, report.ExpectedLength)
+		if err != nil {
+			continue
+		}
+		hashes[t.Namespace] = h
+		writers = append(writers, h)
+	}
+	n, err := io.Copy(io.MultiWriter(writers...), r)
+	if err != nil {
+		return report, err
+	}
+	report.Length = n
+
+	results := map[string]string{}
+	for ns, h := range hashes {
+		results[ns] = h.topicHash(n)
+	}
+	for _, t := range topics {
+		got, ok := results[t.Namespace]
+		result := TopicResult{Topic: t, Status: Unsupported, Got: got}
+		if ok {
+			switch {
+			case sameHash(t.Hash, got):
+				result.Status = Matched
+			case t.Namespace == string(BTIH) || t.Namespace == string(BTMH):
+				//different piece length or name, not a content mismatch
+			default:
+				result.Status = Mismatched
+			}
+		}
+		report.Topics = append(report.Topics, result)
+	}
+	return report, nil
+}
+
+// sameHash compares two hashes that may be written in hex or base32.
+func sameHash(a, b string) bool {
+	da, db := decodeHash(a), decodeHash(b)
+	return da != nil && bytes.Equal(da, db)
+}
+
+func decodeHash(s string) []byte {
+	if b, err := hex.DecodeString(s); err == nil {
+		return b
+	}
+	if b, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(s)); err == nil {
+		return b
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package magneturi
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	content := strings.Repeat("A", 1025)
+	tests := []struct {
+		name         string
+		m            *MagnetURI
+		content      string
+		wantStatus   []VerifyStatus
+		wantLengthOK bool
+		wantOK       bool
+		wantErr      bool
+	}{
+		{
+			name:         "all generated topics match",
+			m:            MustParse("magnet:?xt.1=urn:ed2k:809D2CCA45678F9E8F4ABE56D266FA39&xt.2=urn:tree:tiger:PZMRYHGY6LTBEH63ZWAHDORHSYTLO4LEFUIKHWY&xt.3=urn:sha1:UUHHSQPHQXN5X6EMYK6CD7IJ7BHZTE77&xt.4=urn:btih:376604d37327c99eda552f7336d4cedf7cfe0960&xt.5=urn:btmh:1220ee8fe700a01609ddd00dfd5db0b92590944757b1ba46fd34f6c1e5b4d8714c97&xl=1025&dn=test.bin"),
+			content:      content,
+			wantStatus:   []VerifyStatus{Matched, Matched, Matched, Matched, Matched},
+			wantLengthOK: true,
+			wantOK:       true,
+		},
+		{
+			name:         "hex sha1 and foreign btih",
+			m:            MustParse("magnet:?xt.1=urn:sha1:a50e7941e785dbdbf88cc2bc21fd09f84f9993ff&xt.2=urn:btih:QHQXPYWMACKDWKP47RRVIV7VOURXFE5Q&xt.3=urn:md5:00000000000000000000000000000000"),
+			content:      content,
+			wantStatus:   []VerifyStatus{Matched, Unsupported, Unsupported},
+			wantLengthOK: true,
+			wantOK:       true,
+		},
+		{
+			name:         "mismatch",
+			m:            MustParse("magnet:?xt=urn:ed2k:809D2CCA45678F9E8F4ABE56D266FA39&xl=1025"),
+			content:      strings.Repeat("B", 1025),
+			wantStatus:   []VerifyStatus{Mismatched},
+			wantLengthOK: true,
+			wantOK:       false,
+		},
+		{
+			name:         "length mismatch",
+			m:            MustParse("magnet:?xt=urn:ed2k:809D2CCA45678F9E8F4ABE56D266FA39&xl=1026"),
+			content:      content,
+			wantStatus:   []VerifyStatus{Matched},
+			wantLengthOK: false,
+			wantOK:       false,
+		},
+		{
+			name:    "invalid xl",
+			m:       MustParse("magnet:?xt=urn:ed2k:809D2CCA45678F9E8F4ABE56D266FA39&xl=big"),
+			content: content,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := Verify(tt.m, strings.NewReader(tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var status []VerifyStatus
+			for _, r := range report.Topics {
+				status = append(status, r.Status)
+			}
+			if !reflect.DeepEqual(status, tt.wantStatus) {
+				t.Errorf("Verify() status = %v, want %v", status, tt.wantStatus)
+			}
+			if got := report.LengthMatched(); got != tt.wantLengthOK {
+				t.Errorf("VerifyReport.LengthMatched() = %v, want %v", got, tt.wantLengthOK)
+			}
+			if got := report.OK(); got != tt.wantOK {
+				t.Errorf("VerifyReport.OK() = %v, want %v", got, tt.wantOK)
+			}
+		})
+	}
+}
@@ -4,11 +4,21 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"os"
 
 	"github.com/nmmh/magneturi/magneturi"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		serve(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "trackers" {
+		trackers(os.Args[2:])
+		return
+	}
+
 	var (
 		rawMagnetURI string
 		softParse    bool
@@ -25,7 +35,12 @@ func main() {
 		log.Fatal(err)
 	}
 
+	if err := m.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
 	fmt.Println(m)
+	fmt.Println(m.Describe())
 	//pretty print some info
 	m.PrintVerbose()
 
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/nmmh/magneturi/magneturi/server"
+)
+
+// serve runs "magneturi serve", the HTTP service of the server package.
+func serve(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:8080", "the address to listen on")
+	maxBody := fs.Int64("maxbody", server.DefaultMaxBodySize, "the largest request body accepted, in bytes")
+	openAPI := fs.Bool("openapi", false, "print the OpenAPI spec of the endpoints and exit")
+	fs.Parse(args)
+
+	if *openAPI {
+		spec, err := server.OpenAPI()
+		if err != nil {
+			log.Fatal(err)
+		}
+		os.Stdout.Write(append(spec, '\n'))
+		return
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           server.New(server.Options{MaxBodySize: *maxBody}),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	log.Printf("serving on %s", *addr)
+	log.Fatal(srv.ListenAndServe())
+}
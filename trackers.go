@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nmmh/magneturi/magneturi"
+)
+
+// trackers runs "magneturi trackers <command>".
+func trackers(args []string) {
+	if len(args) == 0 || args[0] != "add" {
+		fmt.Fprintln(os.Stderr, "usage: magneturi trackers add -list <file or url> [-max n] [-minscore s] [link ...]")
+		os.Exit(2)
+	}
+	fs := flag.NewFlagSet("trackers add", flag.ExitOnError)
+	list := fs.String("list", "", "the tracker list, a file or an http(s) URL")
+	maxTrackers := fs.Int("max", 0, "the most trackers a link may have, 0 for no limit")
+	minScore := fs.Float64("minscore", 0, "skip listed trackers with a health score below this")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: magneturi trackers add -list <file or url> [-max n] [-minscore s] [link ...]")
+		fmt.Fprintln(fs.Output(), "Adds the listed trackers to each link, read from the arguments or one per line from stdin.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args[1:])
+	if *list == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	data, err := readTrackerList(*list)
+	if err != nil {
+		log.Fatal(err)
+	}
+	opts := magneturi.AddTrackersOptions{MaxTrackers: *maxTrackers, MinScore: *minScore}
+	add := func(link string) {
+		m, err := magneturi.Parse(link, false)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if _, err := m.AddTrackersFromWith(bytes.NewReader(data), opts); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(m)
+	}
+
+	if fs.NArg() > 0 {
+		for _, link := range fs.Args() {
+			add(link)
+		}
+		return
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		if link := strings.TrimSpace(scanner.Text()); link != "" {
+			add(link)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// A remote tracker list must arrive within trackerListTimeout and be
+// at most maxTrackerListSize bytes.
+const (
+	trackerListTimeout = 30 * time.Second
+	maxTrackerListSize = 4 << 20
+)
+
+// readTrackerList reads the list once, it is applied to every link.
+func readTrackerList(name string) ([]byte, error) {
+	if !strings.HasPrefix(name, "http://") && !strings.HasPrefix(name, "https://") {
+		return os.ReadFile(name)
+	}
+	client := &http.Client{Timeout: trackerListTimeout}
+	resp, err := client.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", name, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxTrackerListSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %v", name, err)
+	}
+	if len(data) > maxTrackerListSize {
+		return nil, fmt.Errorf("fetching %s: tracker list larger than %d bytes", name, maxTrackerListSize)
+	}
+	return data, nil
+}